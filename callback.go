@@ -1,22 +1,95 @@
 package gorm
 
+//the steps of the operations are registered as callbacks, so they can be reordered, replaced or removed like any other
+func newCallbacks() *Callbacks {
+	c := &Callbacks{}
+
+	//every step runs after the previous one, so the callbacks which contradict the steps are reported as cycles
+	c.Create().Register(cbBeginTransaction, (*Scope).beginTransactionCallback)
+	c.Create().After(cbBeginTransaction).Register(cbBeforeCreate, (*Scope).beforeCreateCallback)
+	c.Create().After(cbBeforeCreate).Register(cbSaveBeforeAssociations, (*Scope).saveBeforeAssociationsCallback)
+	c.Create().After(cbSaveBeforeAssociations).Register(cbUpdateTimeStampForCreate, (*Scope).updateTimeStampForCreateCallback)
+	c.Create().After(cbUpdateTimeStampForCreate).Register(cbCreate, (*Scope).insertCallback)
+	c.Create().After(cbCreate).Register(cbForceReloadAfterCreate, (*Scope).forceReloadAfterCreateCallback)
+	c.Create().After(cbForceReloadAfterCreate).Register(cbSaveAfterAssociations, (*Scope).saveAfterAssociationsCallback)
+	c.Create().After(cbSaveAfterAssociations).Register(cbAfterCreate, (*Scope).afterCreateCallback)
+	c.Create().After(cbAfterCreate).Register(cbCommitOrRollback, (*Scope).commitOrRollbackCallback)
+
+	c.Update().Register(cbAssignUpdatingAttributes, (*Scope).assignUpdatingAttributesCallback)
+	c.Update().After(cbAssignUpdatingAttributes).Register(cbBeginTransaction, (*Scope).beginTransactionCallback)
+	c.Update().After(cbBeginTransaction).Register(cbBeforeUpdate, (*Scope).beforeUpdateCallback)
+	c.Update().After(cbBeforeUpdate).Register(cbSaveBeforeAssociations, (*Scope).saveBeforeAssociationsCallback)
+	c.Update().After(cbSaveBeforeAssociations).Register(cbUpdateTimeStampForUpdate, (*Scope).updateTimeStampForUpdateCallback)
+	c.Update().After(cbUpdateTimeStampForUpdate).Register(cbUpdate, (*Scope).updateRowsCallback)
+	c.Update().After(cbUpdate).Register(cbSaveAfterAssociations, (*Scope).saveAfterAssociationsCallback)
+	c.Update().After(cbSaveAfterAssociations).Register(cbAfterUpdate, (*Scope).afterUpdateCallback)
+	c.Update().After(cbAfterUpdate).Register(cbCommitOrRollback, (*Scope).commitOrRollbackCallback)
+
+	c.Delete().Register(cbBeginTransaction, (*Scope).beginTransactionCallback)
+	c.Delete().After(cbBeginTransaction).Register(cbBeforeDelete, (*Scope).beforeDeleteCallback)
+	c.Delete().After(cbBeforeDelete).Register(cbDeleteAssociations, (*Scope).deleteAssociationsCallback)
+	c.Delete().After(cbDeleteAssociations).Register(cbDelete, (*Scope).deleteRowsCallback)
+	c.Delete().After(cbDelete).Register(cbAfterDelete, (*Scope).afterDeleteCallback)
+	c.Delete().After(cbAfterDelete).Register(cbCommitOrRollback, (*Scope).commitOrRollbackCallback)
+
+	c.Query().Register(cbQuery, (*Scope).selectRowsCallback)
+	c.Query().After(cbQuery).Register(cbPreload, (*Scope).preloadCallback)
+	c.Query().After(cbPreload).Register(cbAfterQuery, (*Scope).afterQueryCallback)
+
+	c.Restore().Register(cbBeginTransaction, (*Scope).beginTransactionCallback)
+	c.Restore().After(cbBeginTransaction).Register(cbBeforeRestore, (*Scope).beforeRestoreCallback)
+	c.Restore().After(cbBeforeRestore).Register(cbRestore, (*Scope).restoreRowsCallback)
+	c.Restore().After(cbRestore).Register(cbAfterRestore, (*Scope).afterRestoreCallback)
+	c.Restore().After(cbAfterRestore).Register(cbCommitOrRollback, (*Scope).commitOrRollbackCallback)
+
+	return c
+}
+
 func (c *Callbacks) clone() *Callbacks {
 	return &Callbacks{
 		creates:    c.creates,
 		updates:    c.updates,
 		deletes:    c.deletes,
 		queries:    c.queries,
+		readRows:   c.readRows,
 		rowQueries: c.rowQueries,
-		processors: c.processors,
+		restores:   c.restores,
+		processors: append(CallbacksProcessors(nil), c.processors...),
 	}
 }
 
 // reorder all registered processors, and reset CURD callbacks
-func (c *Callbacks) reorder() {
-	c.processors.reorder(c)
+func (c *Callbacks) reorder() error {
+	return c.processors.reorder(c)
 }
 
-// Create could be used to register callbacks for creating object
+// Describe returns the names of the callbacks of every kind ("create", "update", "delete", "query", "row" and
+// "restore"), in the order they run. The error tells about the Before and After which name callbacks that are not registered
+//     order, err := db.Callback().Describe()
+//     fmt.Println(order["create"])
+func (c *Callbacks) Describe() (map[string][]string, error) {
+	var (
+		result = make(map[string][]string)
+		errs   GormErrors
+	)
+	for kind := createCallback; kind <= restoreCallback; kind++ {
+		sorted, unresolved, err := c.processors.ofKind(kind).sortProcessors()
+		if err != nil {
+			errs = errs.Add(err)
+		}
+		errs = errs.Add(unresolved...)
+		result[callbackKindNames[kind]] = sorted.names()
+	}
+	if len(errs) > 0 {
+		return result, errs
+	}
+	return result, nil
+}
+
+// Create could be used to register callbacks for creating object. The work is done by the callbacks
+// "gorm:begin_transaction", "gorm:before_create", "gorm:save_before_associations", "gorm:update_time_stamp_when_create",
+// "gorm:create", "gorm:force_reload_after_create", "gorm:save_after_associations", "gorm:after_create" and
+// "gorm:commit_or_rollback_transaction", in this order. A callback registered without Before or After runs last
 //     db.Callback().Create().After("gorm:create").Register("plugin:run_after_create", func(*Scope) {
 //       // business logic
 //       ...
@@ -28,22 +101,35 @@ func (c *Callbacks) Create() *CallbacksProcessor {
 	return &CallbacksProcessor{kind: createCallback, parent: c}
 }
 
-// Update could be used to register callbacks for updating object, refer `Create` for usage
+// Update could be used to register callbacks for updating object, refer `Create` for usage. The work is done by
+// "gorm:assign_updating_attributes", "gorm:begin_transaction", "gorm:before_update", "gorm:save_before_associations",
+// "gorm:update_time_stamp_when_update", "gorm:update", "gorm:save_after_associations", "gorm:after_update" and
+// "gorm:commit_or_rollback_transaction"
 func (c *Callbacks) Update() *CallbacksProcessor {
 	return &CallbacksProcessor{kind: updateCallback, parent: c}
 }
 
-// Delete could be used to register callbacks for deleting object, refer `Create` for usage
+// Delete could be used to register callbacks for deleting object, refer `Create` for usage. The work is done by
+// "gorm:begin_transaction", "gorm:before_delete", "gorm:delete_associations", "gorm:delete", "gorm:after_delete" and
+// "gorm:commit_or_rollback_transaction"
 func (c *Callbacks) Delete() *CallbacksProcessor {
 	return &CallbacksProcessor{kind: deleteCallback, parent: c}
 }
 
 // Query could be used to register callbacks for querying objects with query methods like `Find`, `First`, `Related`, `Association`...
-// Refer `Create` for usage
+// Refer `Create` for usage. The work is done by "gorm:query", "gorm:preload" and "gorm:after_query".
+// While iterating (see DBCon.Iterate), the callbacks placed after "gorm:query" are called for every row read
 func (c *Callbacks) Query() *CallbacksProcessor {
 	return &CallbacksProcessor{kind: queryCallback, parent: c}
 }
 
+// Restore could be used to register callbacks for restoring soft deleted objects, refer `Create` for usage. The work is
+// done by "gorm:begin_transaction", "gorm:before_restore", "gorm:restore", "gorm:after_restore" and
+// "gorm:commit_or_rollback_transaction"
+func (c *Callbacks) Restore() *CallbacksProcessor {
+	return &CallbacksProcessor{kind: restoreCallback, parent: c}
+}
+
 // RowQuery could be used to register callbacks for querying objects with `Row`, `Rows`, refer `Create` for usage
 func (c *Callbacks) RowQuery() *CallbacksProcessor {
 	return &CallbacksProcessor{kind: rowCallback, parent: c}
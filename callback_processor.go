@@ -1,5 +1,10 @@
 package gorm
 
+import (
+	"errors"
+	"fmt"
+)
+
 // After insert a new callback after callback `callbackName`, refer `Callbacks.Create`
 func (c *CallbacksProcessor) After(callbackName string) *CallbacksProcessor {
 	c.after = callbackName
@@ -12,86 +17,71 @@ func (c *CallbacksProcessor) Before(callbackName string) *CallbacksProcessor {
 	return c
 }
 
-// Register a new callback, refer `Callbacks.Create`
-func (c *CallbacksProcessor) Register(callbackName string, callback ScopedFunc) {
+// Register a new callback, refer `Callbacks.Create`. The callback is not registered if the name is taken or if its
+// Before and After make a cycle with the ones of the other callbacks (the built-in steps run one after the other).
+// When they name callbacks which are not registered yet, the callback is registered and the error tells about
+// them : they are followed once those callbacks are registered, see `Callbacks.Describe`
+func (c *CallbacksProcessor) Register(callbackName string, callback ScopedFunc) error {
+	if callbackName == "" {
+		return errors.New(errCallbackName)
+	}
+	index := c.parent.processors.index(c.kind, callbackName)
+	if index != -1 && !c.parent.processors[index].removed {
+		return fmt.Errorf(errCallbackDuplicated, callbackName, callbackKindNames[c.kind])
+	}
+	previous := c.parent.processors
+	if index != -1 {
+		//the removed callback gives its name to the new one
+		c.parent.processors.removeAt(index)
+	}
 	c.name = callbackName
 	c.processor = &callback
 	c.parent.processors.add(c)
-	c.parent.reorder()
+	if err := c.parent.reorder(); err != nil {
+		c.parent.processors = previous
+		return err
+	}
+	if unresolved := c.parent.processors.unresolved(c); len(unresolved) > 0 {
+		return unresolved
+	}
+	return nil
 }
 
-// Remove a registered callback
+// Remove a registered callback. It keeps its place, so it can be put back with Replace
 //     db.Callback().Create().Remove("gorm:update_time_stamp_when_create")
-func (c *CallbacksProcessor) Remove(callbackName string) {
-	//fmt.Printf("[info] removing callback `%v` from %v\n", callbackName, fileWithLineNum())
-	c.name = callbackName
-	c.remove = true
-	c.parent.processors.add(c)
-	c.parent.reorder()
+func (c *CallbacksProcessor) Remove(callbackName string) error {
+	index := c.parent.processors.index(c.kind, callbackName)
+	if index == -1 || c.parent.processors[index].removed {
+		return fmt.Errorf(errCallbackNotFound, callbackName, callbackKindNames[c.kind])
+	}
+	removed := *c.parent.processors[index]
+	removed.removed = true
+	c.parent.processors[index] = &removed
+	return c.parent.reorder()
 }
 
-// Replace a registered callback with new callback
+// Replace a registered (or removed) callback with new callback, which keeps its place
 //     db.Callback().Create().Replace("gorm:update_time_stamp_when_create", func(*Scope) {
 //		   scope.SetColumn("Created", now)
 //		   scope.SetColumn("Updated", now)
 //     })
-func (c *CallbacksProcessor) Replace(callbackName string, callback ScopedFunc) {
-	//fmt.Printf("[info] replacing callback `%v` from %v\n", callbackName, fileWithLineNum())
-	c.name = callbackName
-	c.processor = &callback
-	c.replace = true
-	c.parent.processors.add(c)
-	c.parent.reorder()
+func (c *CallbacksProcessor) Replace(callbackName string, callback ScopedFunc) error {
+	index := c.parent.processors.index(c.kind, callbackName)
+	if index == -1 {
+		return fmt.Errorf(errCallbackNotFound, callbackName, callbackKindNames[c.kind])
+	}
+	replaced := *c.parent.processors[index]
+	replaced.processor = &callback
+	replaced.removed = false
+	c.parent.processors[index] = &replaced
+	return c.parent.reorder()
 }
 
 // Get registered callback
 //    db.Callback().Create().Get("gorm:create")
 func (c *CallbacksProcessor) Get(callbackName string) ScopedFunc {
-	for _, p := range c.parent.processors {
-		if p.name == callbackName && p.kind == c.kind && !c.remove {
-			return *p.processor
-		}
+	if index := c.parent.processors.index(c.kind, callbackName); index != -1 && !c.parent.processors[index].removed {
+		return *c.parent.processors[index].processor
 	}
 	return nil
 }
-
-//sorts callback processors
-func (c *CallbacksProcessor) sortCallbackProcessor(allNames, sortedNames *StrSlice, parent CallbacksProcessors) {
-	if sortedNames.rIndex(c.name) == -1 { // if not sorted
-		if c.before != "" {
-			// if defined before callback
-			if idx := sortedNames.rIndex(c.before); idx != -1 {
-				// if before callback already sorted, append current callback just after it
-				sortedNames.insertAt(idx, c.name)
-			} else if index := allNames.rIndex(c.before); index != -1 {
-				// if before callback exists but haven't sorted, append current callback to last
-				sortedNames.add(c.name)
-				//sort next
-				nextCp := parent[index]
-				nextCp.sortCallbackProcessor(allNames, sortedNames, parent)
-			}
-		}
-
-		if c.after != "" {
-			// if defined after callback
-			if index := sortedNames.rIndex(c.after); index != -1 {
-				// if after callback already sorted, append current callback just before it
-				sortedNames.insertAt(index+1, c.name)
-			} else if idx := allNames.rIndex(c.after); idx != -1 {
-				//sort next
-				// if after callback exists but haven't sorted
-				nextCp := parent[idx]
-				// set after callback's before callback to current callback
-				if nextCp.before == "" {
-					nextCp.before = c.name
-				}
-				nextCp.sortCallbackProcessor(allNames, sortedNames, parent)
-			}
-		}
-
-		// if current callback haven't been sorted, append it to last
-		if sortedNames.rIndex(c.name) == -1 {
-			sortedNames.add(c.name)
-		}
-	}
-}
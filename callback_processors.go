@@ -10,64 +10,178 @@ func (p *CallbacksProcessors) len() int {
 	return len(*p)
 }
 
-func (p *CallbacksProcessors) reorder(ofCallback *Callbacks) {
-	var creates, updates, deletes, queries, rowQueries CallbacksProcessors
-	//collect processors by their kind
-	for _, processor := range *p {
-		if processor.name != "" {
-			switch processor.kind {
-			case createCallback:
-				creates.add(processor)
-			case updateCallback:
-				updates.add(processor)
-			case deleteCallback:
-				deletes.add(processor)
-			case queryCallback:
-				queries.add(processor)
-			case rowCallback:
-				rowQueries.add(processor)
-			}
+//copies, since the processors are shared with the clones of the callbacks
+func (p *CallbacksProcessors) removeAt(index int) {
+	*p = append(append(CallbacksProcessors(nil), (*p)[:index]...), (*p)[index+1:]...)
+}
+
+//position of the processor with the name, -1 if there is none
+func (p CallbacksProcessors) index(kind uint8, name string) int {
+	for idx, cp := range p {
+		if cp.kind == kind && cp.name == name {
+			return idx
 		}
 	}
-	//avoid unnecessary calls
-	if creates.len() > 0 {
-		ofCallback.creates = creates.sortProcessors()
+	return -1
+}
+
+//the processors of the kind, in the order they were registered
+func (p CallbacksProcessors) ofKind(kind uint8) CallbacksProcessors {
+	var result CallbacksProcessors
+	for _, processor := range p {
+		if processor.kind == kind {
+			result.add(processor)
+		}
 	}
-	if updates.len() > 0 {
-		ofCallback.updates = updates.sortProcessors()
+	return result
+}
+
+func (p CallbacksProcessors) funcs() ScopedFuncs {
+	var result ScopedFuncs
+	for _, processor := range p {
+		result.add(processor.processor)
 	}
-	if deletes.len() > 0 {
-		ofCallback.deletes = deletes.sortProcessors()
+	return result
+}
+
+func (p CallbacksProcessors) names() []string {
+	var result []string
+	for _, processor := range p {
+		result = append(result, processor.name)
 	}
-	if queries.len() > 0 {
-		ofCallback.queries = queries.sortProcessors()
+	return result
+}
+
+//the callbacks are replaced only if every kind can be sorted
+func (p CallbacksProcessors) reorder(ofCallback *Callbacks) error {
+	sorted := make(map[uint8]CallbacksProcessors)
+	for kind := createCallback; kind <= restoreCallback; kind++ {
+		processors, _, err := p.ofKind(kind).sortProcessors()
+		if err != nil {
+			return err
+		}
+		sorted[kind] = processors
 	}
-	if rowQueries.len() > 0 {
-		ofCallback.rowQueries = rowQueries.sortProcessors()
+	ofCallback.creates = sorted[createCallback].funcs()
+	ofCallback.updates = sorted[updateCallback].funcs()
+	ofCallback.deletes = sorted[deleteCallback].funcs()
+	ofCallback.queries = sorted[queryCallback].funcs()
+	ofCallback.readRows = nil
+	if index := sorted[queryCallback].index(queryCallback, cbQuery); index != -1 {
+		ofCallback.readRows = sorted[queryCallback][index+1:].funcs()
 	}
-
+	ofCallback.rowQueries = sorted[rowCallback].funcs()
+	ofCallback.restores = sorted[restoreCallback].funcs()
+	return nil
 }
 
-// sortProcessors sort callback processors based on its before, after, remove, replace
-func (p CallbacksProcessors) sortProcessors() ScopedFuncs {
-	var allNames, sortedNames StrSlice
-	var sortedFuncs ScopedFuncs
+// sortProcessors sorts the callback processors (of the same kind) topologically, by their before and after. Among
+// the processors ready to run, each one keeps the place it's registered at, like being inserted right after its after
+// (or right before its before), otherwise appended. A before or after which names a callback that is not registered
+// is ignored and returned as unresolved. The removed processors keep their place, but they are not returned
+func (p CallbacksProcessors) sortProcessors() (CallbacksProcessors, GormErrors, error) {
+	var (
+		sorted       CallbacksProcessors
+		unresolved   GormErrors
+		keys         = make(map[int][]int)
+		successors   = make([][]int, len(p))
+		predecessors = make([][]int, len(p))
+		inDegree     = make([]int, len(p))
+		placed       = make([]bool, len(p))
+	)
+	edge := func(from, to int) {
+		successors[from] = append(successors[from], to)
+		predecessors[to] = append(predecessors[to], from)
+		inDegree[to]++
+	}
+
+	for idx, cp := range p {
+		if cp.before == cp.name || cp.after == cp.name {
+			return nil, unresolved, fmt.Errorf(errCallbackCycle, cp.name, callbackKindNames[cp.kind])
+		}
+		if after := p.index(cp.kind, cp.after); cp.after != "" && after != -1 {
+			edge(after, idx)
+		}
+		if before := p.index(cp.kind, cp.before); cp.before != "" && before != -1 {
+			edge(idx, before)
+		}
+		if !cp.removed {
+			unresolved = unresolved.Add(p.unresolved(cp)...)
+		}
+	}
+	for idx := range p {
+		p.sortKey(idx, keys, make(map[int]bool))
+	}
 
-	for _, cp := range p {
-		// show warning message the callback name already exists
-		if index := allNames.rIndex(cp.name); index > -1 && !cp.replace && !cp.remove {
-			fmt.Printf("[warning] duplicated callback `%v` from %v\n", cp.name, fileWithLineNum())
+	for range p {
+		next := -1
+		for idx := range p {
+			if !placed[idx] && inDegree[idx] == 0 && (next == -1 || lessSortKey(keys[idx], keys[next])) {
+				next = idx
+			}
+		}
+		if next == -1 {
+			//every processor left waits for another one : walking back the ones they wait for ends in the cycle
+			seen := make(map[int]bool)
+			for next = 0; placed[next]; next++ {
+			}
+			for !seen[next] {
+				seen[next] = true
+				for _, predecessor := range predecessors[next] {
+					if !placed[predecessor] {
+						next = predecessor
+						break
+					}
+				}
+			}
+			return nil, unresolved, fmt.Errorf(errCallbackCycle, p[next].name, callbackKindNames[p[next].kind])
+		}
+		placed[next] = true
+		for _, successor := range successors[next] {
+			inDegree[successor]--
+		}
+		if !p[next].removed {
+			sorted.add(p[next])
 		}
-		allNames.add(cp.name)
 	}
-	for _, cp := range p {
-		cp.sortCallbackProcessor(&allNames, &sortedNames, p)
+	return sorted, unresolved, nil
+}
+
+//the before and after of the processor which name callbacks that are not registered (or removed)
+func (p CallbacksProcessors) unresolved(cp *CallbacksProcessor) GormErrors {
+	var result GormErrors
+	if index := p.index(cp.kind, cp.after); cp.after != "" && (index == -1 || p[index].removed) {
+		result = result.Add(fmt.Errorf(errCallbackUnknown, cp.name, callbackKindNames[cp.kind], "after", cp.after))
 	}
+	if index := p.index(cp.kind, cp.before); cp.before != "" && (index == -1 || p[index].removed) {
+		result = result.Add(fmt.Errorf(errCallbackUnknown, cp.name, callbackKindNames[cp.kind], "before", cp.before))
+	}
+	return result
+}
+
+//the place of the processor among the ones ready to run : the place of its after (or before) followed by its own,
+//where the last registered goes closest, otherwise the place of its registration. An after (or before) leading
+//back to the processor is not followed
+func (p CallbacksProcessors) sortKey(idx int, keys map[int][]int, visiting map[int]bool) []int {
+	if key, ok := keys[idx]; ok {
+		return key
+	}
+	visiting[idx] = true
+	cp, key := p[idx], []int{idx}
+	if after := p.index(cp.kind, cp.after); cp.after != "" && after != -1 && !visiting[after] {
+		key = append(append([]int(nil), p.sortKey(after, keys, visiting)...), 1, -idx)
+	} else if before := p.index(cp.kind, cp.before); cp.before != "" && before != -1 && !visiting[before] {
+		key = append(append([]int(nil), p.sortKey(before, keys, visiting)...), -1, idx)
+	}
+	keys[idx] = key
+	return key
+}
 
-	for _, name := range sortedNames {
-		if index := allNames.rIndex(name); !p[index].remove {
-			sortedFuncs.add(p[index].processor)
+func lessSortKey(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
 		}
 	}
-	return sortedFuncs
+	return len(a) < len(b)
 }
@@ -1,10 +1,14 @@
 package gorm
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -157,6 +161,14 @@ func (con *DBCon) Unscoped() *DBCon {
 	return clone
 }
 
+// OnlyTrashed return only the soft deleted records, refer Soft Delete
+//     db.OnlyTrashed().Find(&users)
+func (con *DBCon) OnlyTrashed() *DBCon {
+	clone := con.clone(nil)
+	clone.search.setOnlyTrashed()
+	return clone
+}
+
 // Attrs initialize struct with argument if record not found with `FirstOrInit` or `FirstOrCreate`
 // Note : no scope
 func (con *DBCon) Attrs(attrs ...interface{}) *DBCon {
@@ -221,8 +233,12 @@ func (con *DBCon) Callback() *Callbacks {
 	return con.parent.callbacks
 }
 
-// SetLogger replace default logger
-func (con *DBCon) SetLogger(log logger) {
+// SetLogger replace default logger, nil restores it
+//     db.SetLogger(gorm.SlogLogger{Logger: slog.Default()})
+func (con *DBCon) SetLogger(log Logger) {
+	if log == nil {
+		log = defaultLogger
+	}
 	con.logger = log
 }
 
@@ -240,9 +256,52 @@ func (con *DBCon) SetLogMode(mode int) {
 	con.logMode = mode
 }
 
+// SetSlowThreshold statements running longer than threshold are logged as warnings, even if the log mode is off.
+// Zero disables it
+//     db.SetSlowThreshold(200 * time.Millisecond)
+func (con *DBCon) SetSlowThreshold(threshold time.Duration) *DBCon {
+	con.slowThreshold = threshold
+	return con
+}
+
+// SetStatementObserver sets the observer notified around every statement sent to the driver, nil removes it
+//     metrics := gorm.NewStatementMetrics()
+//     db.SetStatementObserver(metrics)
+func (con *DBCon) SetStatementObserver(observer StatementObserver) *DBCon {
+	con.observer = observer
+	return con
+}
+
+// SetTracer sets the tracer which gets a span for every operation, with children for the statements,
+// preloads and associations saves. nil restores the default, which traces nothing
+//     db.SetTracer(gorm.NewSpanRecorder())
+func (con *DBCon) SetTracer(tracer Tracer) *DBCon {
+	if tracer == nil {
+		tracer = NoopTracer{}
+	}
+	con.tracer = tracer
+	return con
+}
+
+// SetRedaction sets how the logged statements, the statement errors and the StatementObserver show the vars.
+// By default the values of the fields tagged `sensitive` and the values marked with Sensitive are shown as '***'
+//     db.SetRedaction(gorm.RedactAll)
+func (con *DBCon) SetRedaction(policy RedactionPolicy) *DBCon {
+	con.redaction = policy
+	return con
+}
+
+// SetTxOptions set the default transaction options, used by `Begin` and by the transactions
+// opened for every create, update and delete
+//     db.SetTxOptions(&sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+func (con *DBCon) SetTxOptions(opts *sql.TxOptions) *DBCon {
+	con.txOptions = opts
+	return con
+}
+
 // SingularTable use singular table by default
 func (con *DBCon) SingularTable(enable bool) {
-	con.parent.modelsStructMap = &safeModelStructsMap{l: new(sync.RWMutex), m: make(map[reflect.Type]*ModelStruct)}
+	con.parent.modelsStructMap = &safeModelStructsMap{l: new(sync.RWMutex), build: new(sync.Mutex), m: make(map[reflect.Type]*ModelStruct)}
 	con.parent.singularTable = enable
 }
 
@@ -268,6 +327,8 @@ func (con *DBCon) Scopes(funcs ...DBConFunc) *DBCon {
 // First find first record that match given conditions, order by primary key
 func (con *DBCon) First(entity interface{}, where ...interface{}) *DBCon {
 	newScope := con.NewScope(entity)
+	endSpan := newScope.startOperationSpan(SpanFirst)
+	defer endSpan()
 	newScope.Search.Limit(1)
 
 	if primaryField := newScope.PK(); primaryField != nil {
@@ -283,16 +344,14 @@ func (con *DBCon) First(entity interface{}, where ...interface{}) *DBCon {
 	if len(where) > 0 {
 		newScope.Search.Wheres(where...)
 	}
-	newScope = newScope.postQuery(nil)
-	if con.parent.callbacks.queries.len() > 0 {
-		newScope.callCallbacks(con.parent.callbacks.queries)
-	}
-	return newScope.con
+	return newScope.postQuery(nil).con
 }
 
 // Last find last record that match given conditions, order by primary key
 func (con *DBCon) Last(entity interface{}, where ...interface{}) *DBCon {
 	newScope := con.NewScope(entity)
+	endSpan := newScope.startOperationSpan(SpanLast)
+	defer endSpan()
 	newScope.Search.Limit(1)
 
 	if primaryField := newScope.PK(); primaryField != nil {
@@ -308,34 +367,92 @@ func (con *DBCon) Last(entity interface{}, where ...interface{}) *DBCon {
 	if len(where) > 0 {
 		newScope.Search.Wheres(where...)
 	}
-	newScope = newScope.postQuery(nil)
-	if con.parent.callbacks.queries.len() > 0 {
-		newScope.callCallbacks(con.parent.callbacks.queries)
-	}
-	return newScope.con
+	return newScope.postQuery(nil).con
 }
 
 // Find find records that match given conditions
 func (con *DBCon) Find(out interface{}, where ...interface{}) *DBCon {
 	newScope := con.NewScope(out)
+	endSpan := newScope.startOperationSpan(SpanFind)
+	defer endSpan()
 	if len(where) > 0 {
 		newScope.Search.Wheres(where...)
 	}
-	newScope = newScope.postQuery(nil)
-	if con.parent.callbacks.queries.len() > 0 {
-		newScope.callCallbacks(con.parent.callbacks.queries)
+	return newScope.postQuery(nil).con
+}
+
+// Iterate streams the matching rows, calling `fc` (a `func(*T) error`, where `value` is a `*T`) with a new value
+// for every row, so the result set is never held in memory. An error returned by `fc` stops the iteration.
+// Every row goes through the query callbacks placed after "gorm:query" : the preloads run for every row, as
+// `AfterFind` does unless the "gorm:skip_after_find" setting is true.
+// Note : inside a transaction, preloading while iterating needs a driver which allows more than one open result set
+//     err := db.Where("age > ?", 18).Order("id").Iterate(&User{}, func(user *User) error {
+//         return encoder.Encode(user)
+//     }).Error
+func (con *DBCon) Iterate(value interface{}, fc interface{}) *DBCon {
+	newScope := con.NewScope(value)
+	iterator := reflect.ValueOf(fc)
+	newScope.iterator = &iterator
+	return newScope.postQuery(nil).con
+}
+
+// FindInBatches walks the matching rows in primary key order, `batchSize` rows at a time, filling `dest` (a pointer
+// to a slice) and calling `fc` with the result of every query. Batches are read with `WHERE pk > last` (works for
+// composite primary keys too) rather than with OFFSET, so any previous Order is replaced.
+// An error returned by `fc` stops the walk. `fc` can change `dest`, the next batch overwrites it anyway
+//     db.Where("processed = ?", false).FindInBatches(&users, 500, func(tx *gorm.DBCon, batch int) error {
+//         return process(users)
+//     })
+func (con *DBCon) FindInBatches(dest interface{}, batchSize int, fc func(tx *DBCon, batch int) error) *DBCon {
+	var (
+		result       = con.clone(nil)
+		scope        = con.NewScope(dest)
+		pks          = scope.PKs()
+		query        = con.Limit(batchSize)
+		rowsAffected int64
+	)
+	if value := reflect.ValueOf(dest); value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Slice {
+		result.AddError(fmt.Errorf(errBatchesDest, dest))
+		return result
+	}
+	if pks.len() == 0 {
+		result.AddError(fmt.Errorf(errNoPrimaryKey, scope.GetModelStruct().ModelType))
+		return result
+	}
+	for idx, pk := range pks {
+		query = query.Order(fmt.Sprintf("%v.%v %v", scope.quotedTableName(), con.quote(pk.DBName), strAscendent), idx == 0)
+	}
+
+	for batch, next := 1, query; ; batch++ {
+		tx := next.Find(dest)
+		rowsAffected += tx.RowsAffected
+		if tx.Error != nil {
+			result.AddError(tx.Error)
+			break
+		}
+		if tx.RowsAffected == 0 {
+			break
+		}
+		//read before fc, which can change dest
+		keyset, args := scope.keysetCondition(scope.elementScope(scope.rValue.Len() - 1))
+		if err := fc(tx, batch); err != nil {
+			result.AddError(err)
+			break
+		}
+		if tx.RowsAffected < int64(batchSize) {
+			break
+		}
+		next = query.Where(keyset, args...)
 	}
-	return newScope.con
+
+	result.RowsAffected = rowsAffected
+	return result
 }
 
 // Scan scan value to a struct
 func (con *DBCon) Scan(dest interface{}) *DBCon {
 	newScope := con.NewScope(con.search.Value)
-	newScope = newScope.postQuery(dest)
-	if con.parent.callbacks.queries.len() > 0 {
-		newScope.callCallbacks(con.parent.callbacks.queries)
-	}
-	return newScope.con
+	return newScope.postQuery(dest).con
 }
 
 // Row return `*sql.Row` with given conditions
@@ -409,9 +526,6 @@ func (con *DBCon) FirstOrCreate(out interface{}, where ...interface{}) *DBCon {
 		newScope := conClone.NewScope(out)
 		newScope.Search.Wheres(where...).initialize(newScope)
 		newScope = newScope.postCreate()
-		if conClone.parent.callbacks.creates.len() > 0 {
-			newScope.callCallbacks(conClone.parent.callbacks.creates)
-		}
 		return newScope.con
 	} else if conClone.search.hasAssign() {
 		scope := conClone.NewScope(out)
@@ -421,10 +535,6 @@ func (con *DBCon) FirstOrCreate(out interface{}, where ...interface{}) *DBCon {
 		} else {
 			scope = scope.postUpdate(nil)
 		}
-
-		if conClone.parent.callbacks.updates.len() > 0 {
-			scope.callCallbacks(conClone.parent.callbacks.updates)
-		}
 		return scope.con
 	}
 	return conClone
@@ -433,11 +543,9 @@ func (con *DBCon) FirstOrCreate(out interface{}, where ...interface{}) *DBCon {
 // Updates update attributes with callbacks
 func (con *DBCon) Updates(values interface{}, ignoreProtectedAttrs ...bool) *DBCon {
 	newScope := con.NewScope(con.search.Value)
-	newScope = newScope.postUpdate(values)
-	if con.parent.callbacks.updates.len() > 0 {
-		newScope.callCallbacks(con.parent.callbacks.updates)
-	}
-	return newScope.con
+	endSpan := newScope.startOperationSpan(SpanUpdate)
+	defer endSpan()
+	return newScope.postUpdate(values).con
 }
 
 // Update update attributes with callbacks
@@ -448,11 +556,9 @@ func (con *DBCon) Update(attrs ...interface{}) *DBCon {
 // UpdateColumns update attributes without callbacks
 func (con *DBCon) UpdateColumns(values interface{}) *DBCon {
 	newScope := con.NewScope(con.search.Value)
-	newScope = newScope.Set(gormSettingUpdateColumn, true).Set(gormSettingSaveAssoc, false).postUpdate(values)
-	if con.parent.callbacks.updates.len() > 0 {
-		newScope.callCallbacks(con.parent.callbacks.updates)
-	}
-	return newScope.con
+	endSpan := newScope.startOperationSpan(SpanUpdate)
+	defer endSpan()
+	return newScope.Set(gormSettingUpdateColumn, true).Set(gormSettingSaveAssoc, false).postUpdate(values).con
 }
 
 // UpdateColumn update attributes without callbacks
@@ -461,49 +567,87 @@ func (con *DBCon) UpdateColumn(attrs ...interface{}) *DBCon {
 	return con.UpdateColumns(result)
 }
 
-// Save update value in database, if the value doesn't have primary key, will insert it
+// Save update value in database, if the value doesn't have primary key, will insert it. A value which has primary key
+// is inserted too if no row was updated, unless it has a Version : then it fails with ErrStaleObject
 func (con *DBCon) Save(value interface{}) *DBCon {
 	scope := con.NewScope(value)
+	endSpan := scope.startOperationSpan(SpanSave)
+	defer endSpan()
 	if !scope.PrimaryKeyZero() {
 		scope = scope.postUpdate(nil)
-		if con.parent.callbacks.updates.len() > 0 {
-			scope.callCallbacks(con.parent.callbacks.updates)
-		}
 		if scope.con.Error == nil && scope.con.RowsAffected == 0 {
-			return con.empty().FirstOrCreate(value)
+			return scope.con.empty().FirstOrCreate(value)
 		}
 		return scope.con
 	}
-	scope = scope.postCreate()
-	if con.parent.callbacks.creates.len() > 0 {
-		scope.callCallbacks(con.parent.callbacks.creates)
-	}
-	return scope.con
+	return scope.postCreate().con
 }
 
-// Create insert the value into database
+// Create insert the value into database. A slice is inserted with a single statement
+//     db.Create(&user)
+//     db.Create(&[]User{{Name: "jinzhu"}, {Name: "badu"}})
 func (con *DBCon) Create(value interface{}) *DBCon {
-	scope := con.NewScope(value).postCreate()
-	if con.parent.callbacks.creates.len() > 0 {
-		scope.callCallbacks(con.parent.callbacks.creates)
-	}
-	return scope.con
+	return con.CreateInBatches(value, 0)
 }
 
-// Delete delete value match given conditions, if the value has primary key, then will including the primary key as condition
+// CreateInBatches insert the slice value into database, with one statement for every `batchSize` elements
+//     db.CreateInBatches(&users, 100)
+func (con *DBCon) CreateInBatches(value interface{}, batchSize int) *DBCon {
+	scope := con.NewScope(value)
+	endSpan := scope.startOperationSpan(SpanCreate)
+	defer endSpan()
+	scope.batchSize = batchSize
+	return scope.postCreate().con
+}
+
+// OnConflict turns the following Create into an upsert, for single values and slices alike.
+// Set `Columns` to have the values read back from the database after the upsert. Without `Columns`, an upsert
+// which updates leaves the blank primary keys blank, unless they are returned (postgres) or the single row
+// is reported as inserted (mysql)
+//     db.OnConflict(gorm.OnConflict{Columns: []string{"email"}, UpdateColumns: []string{"name"}}).Create(&user)
+//     db.OnConflict(gorm.OnConflict{Columns: []string{"code"}, UpdateAll: true, Except: []string{"CreatedAt"}}).Create(&products)
+//     db.OnConflict(gorm.OnConflict{DoNothing: true}).Create(&users)
+func (con *DBCon) OnConflict(conflict OnConflict) *DBCon {
+	return con.set(gormSettingOnConflict, &conflict)
+}
+
+// Delete delete value match given conditions, if the value has primary key, then will including the primary key as condition.
+// When the value has a primary key, the has one and has many relations tagged `cascade:delete` (or selected) are
+// deleted too and the join rows of the many to many ones are removed (unless the value is only soft deleted) : all
+// in the same transaction. Deleting by conditions only, without primary key, cascades nothing
+//     db.Select("Orders", "Profile").Delete(&user)
+//     db.Delete(&User{}, "age > ?", 100) // the relations of the deleted users are kept
 func (con *DBCon) Delete(value interface{}, where ...interface{}) *DBCon {
 	scope := con.NewScope(value)
+	endSpan := scope.startOperationSpan(SpanDelete)
+	defer endSpan()
 	scope.Search.Wheres(where...)
-	scope = scope.postDelete()
-	if con.parent.callbacks.deletes.len() > 0 {
-		scope.callCallbacks(con.parent.callbacks.deletes)
-	}
-	return scope.con
+	return scope.postDelete().con
+}
+
+// Restore undelete the soft deleted records which match given conditions, if the value has primary key, then will
+// including the primary key as condition. `BeforeRestore` and `AfterRestore` methods of the value are called
+//     db.Restore(&user)
+//     db.Restore(&User{}, "deleted_at > ?", since)
+func (con *DBCon) Restore(value interface{}, where ...interface{}) *DBCon {
+	scope := con.NewScope(value)
+	endSpan := scope.startOperationSpan(SpanRestore)
+	defer endSpan()
+	scope.Search.Wheres(where...)
+	return scope.postRestore().con
+}
+
+// ForceDelete delete value match given conditions for real, even if it has a soft delete column
+//     db.ForceDelete(&user)
+func (con *DBCon) ForceDelete(value interface{}, where ...interface{}) *DBCon {
+	return con.Unscoped().Delete(value, where...)
 }
 
 // Exec execute raw sql
 func (con *DBCon) Exec(sql string, values ...interface{}) *DBCon {
 	scope := con.NewScope(nil)
+	endSpan := scope.startOperationSpan(SpanExec)
+	defer endSpan()
 	scope.Raw(scope.Search.exec(scope, sql, values...))
 	return scope.Exec().con
 }
@@ -533,42 +677,205 @@ func (con *DBCon) Debug() *DBCon {
 	return con.clone(nil).LogMode(true)
 }
 
-// Begin begin a transaction
+// WithContext returns a connection which carries the context to every statement it executes,
+// including preloads, associations saves and join table operations
+//     db.WithContext(req.Context()).Preload("Orders").Find(&users)
+func (con *DBCon) WithContext(ctx context.Context) *DBCon {
+	clone := con.clone(nil)
+	clone.ctx = ctx
+	return clone
+}
+
+// Context returns the context of the connection, `context.Background()` if none was set
+func (con *DBCon) Context() context.Context {
+	if con.ctx == nil {
+		return context.Background()
+	}
+	return con.ctx
+}
+
+// Begin begin a transaction, using the connection's default transaction options (see `SetTxOptions`)
+// If the connection is already inside a transaction, a savepoint is created instead, which `Commit` releases
+// and `Rollback` rolls back to, so nested calls can compose
 func (con *DBCon) Begin() *DBCon {
+	return con.BeginTx(nil)
+}
+
+// BeginTx begin a transaction with the given options (isolation level, read only). When `opts` is nil,
+// the connection's default transaction options are used. Options are ignored for nested transactions (savepoints)
+//     tx := db.BeginTx(&sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true})
+func (con *DBCon) BeginTx(opts *sql.TxOptions) *DBCon {
 	c := con.clone(nil)
-	if db, ok := c.sqli.(sqlDb); ok {
-		//clone.db implements Begin() -> call Begin()
-		tx, err := db.Begin()
+	if opts == nil {
+		opts = c.txOptions
+	}
+	switch db := c.sqli.(type) {
+	case sqlDb:
+		//clone.db implements BeginTx() -> call BeginTx()
+		tx, err := db.BeginTx(c.Context(), opts)
 		c.sqli = interface{}(tx).(sqlInterf)
+		c.savePoint = ""
+		c.txHooks = &txHooks{}
 		c.AddError(err)
-	} else {
+	case sqlTx:
+		//clone.db is a transaction -> nest it with a savepoint
+		name := fmt.Sprintf("gorm_sp_%d", atomic.AddUint64(&savePointsCounter, 1))
+		savePointSQL, _, _ := c.parent.dialect.SavePointSQL(name)
+		if _, err := c.sqli.ExecContext(c.Context(), savePointSQL); c.AddError(err) == nil {
+			c.savePoint = name
+			c.txHooks = &txHooks{parent: c.txHooks}
+		}
+	default:
 		c.AddError(ErrCantStartTransaction)
 	}
 	return c
 }
 
-// Commit commit a transaction
+// Commit commit a transaction (or releases the savepoint of a nested one). When the savepoint can't be released,
+// the nested transaction is left open, so it can still be rolled back to
 func (con *DBCon) Commit() *DBCon {
-	if db, ok := con.sqli.(sqlTx); ok {
+	if con.txHooks.isFinished() {
+		con.AddError(ErrInvalidTransaction)
+	} else if con.savePoint != "" {
+		_, releaseSQL, _ := con.parent.dialect.SavePointSQL(con.savePoint)
+		if _, err := con.sqli.ExecContext(con.Context(), releaseSQL); con.AddError(err) == nil {
+			con.txHooks.done(true)
+			con.savePoint = ""
+		}
+	} else if db, ok := con.sqli.(sqlTx); ok {
 		//orm.db implements Commit() and Rollback() -> call Commit()
-		con.AddError(db.Commit())
+		err := db.Commit()
+		con.AddError(err)
+		con.txHooks.done(err == nil)
 	} else {
 		con.AddError(ErrInvalidTransaction)
 	}
 	return con
 }
 
-// Rollback rollback a transaction
+// Rollback rollback a transaction (or rolls back to the savepoint of a nested one)
 func (con *DBCon) Rollback() *DBCon {
-	if db, ok := con.sqli.(sqlTx); ok {
+	if con.txHooks.isFinished() {
+		con.AddError(ErrInvalidTransaction)
+	} else if con.savePoint != "" {
+		_, _, rollbackSQL := con.parent.dialect.SavePointSQL(con.savePoint)
+		_, err := con.sqli.ExecContext(con.Context(), rollbackSQL)
+		con.AddError(err)
+		con.txHooks.done(false)
+		con.savePoint = ""
+	} else if db, ok := con.sqli.(sqlTx); ok {
 		//orm.db implements Commit() and Rollback() -> call Rollback()
 		con.AddError(db.Rollback())
+		con.txHooks.done(false)
 	} else {
 		con.AddError(ErrInvalidTransaction)
 	}
 	return con
 }
 
+// Transaction runs `fc` inside a transaction : commits if `fc` returns nil, rolls back if it returns an error or panics
+// (the panic is raised again after the rollback). Create, update and delete calls made with `tx` won't open
+// their own transaction, since `tx` already holds one. Called on a transaction, it nests using a savepoint
+//     err := db.Transaction(func(tx *gorm.DBCon) error {
+//         if err := tx.Create(&user).Error; err != nil {
+//             return err
+//         }
+//         return tx.Create(&order).Error
+//     })
+func (con *DBCon) Transaction(fc func(tx *DBCon) error) (err error) {
+	tx := con.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	committed := false
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fc(tx); err != nil {
+		return err
+	}
+
+	//a failed commit (or release of the savepoint) is rolled back too
+	if err = tx.Commit().Error; err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// DryRun returns a session which captures the statements instead of executing them. Callbacks and hooks still run,
+// so the statements are exactly the ones which would be executed. Queries return no rows and executions report
+// one affected row, so a QueryRow (e.g. Count) fails with sql.ErrNoRows, while a created record (e.g. by the
+// RETURNING of postgres) is left without a primary key. The dialect is not connected to the database either :
+// HasTable & co. see an empty database, so AutoMigrate captures the statements which create everything
+//     dry := db.DryRun()
+//     dry.Where("name = ?", "jinzhu").Find(&users)
+//     dry.Save(&user)
+//     for _, statement := range dry.Statements() {
+//         fmt.Println(statement.SQL, statement.Vars)
+//     }
+func (con *DBCon) DryRun() *DBCon {
+	dryRunDBOnce.Do(func() {
+		dryRunDB = sql.OpenDB(dryRunConnector{})
+	})
+	c := con.clone(nil)
+	//the parent is copied, because transactions give back the connection of the parent when done
+	parent := *con.parent
+	parent.sqli = dryRunDB
+	parent.dialect = reflect.New(reflect.TypeOf(con.parent.dialect).Elem()).Interface().(Dialect)
+	parent.dialect.SetDB(dryRunDB)
+	parent.parent = &parent
+	c.parent = &parent
+	c.sqli = dryRunDB
+	c.savePoint = ""
+	c.txHooks = nil
+	c.dryRun = &dryRunRecorder{l: new(sync.Mutex)}
+	return c
+}
+
+// Statements returns the statements captured so far by the DryRun session
+func (con *DBCon) Statements() []Statement {
+	return con.dryRun.get()
+}
+
+// ToSQL returns the statements `fc` would execute (joined by ";\n"), along with their variables
+//     sql, vars := db.ToSQL(func(tx *gorm.DBCon) *gorm.DBCon {
+//         return tx.Model(&user).Updates(map[string]interface{}{"name": "hello"})
+//     })
+func (con *DBCon) ToSQL(fc func(tx *DBCon) *DBCon) (string, []interface{}) {
+	var (
+		dry  = con.DryRun()
+		sqls []string
+		vars []interface{}
+	)
+	fc(dry)
+	for _, statement := range dry.Statements() {
+		sqls = append(sqls, statement.SQL)
+		vars = append(vars, statement.Vars...)
+	}
+	return strings.Join(sqls, ";\n"), vars
+}
+
+// OnCommit registers `fn` to be called after the current transaction commits (it is dropped on rollback).
+// Outside a transaction, `fn` is called right away
+//     tx.Create(&order).OnCommit(func() { publish(order) })
+func (con *DBCon) OnCommit(fn func()) *DBCon {
+	if con.txHooks == nil {
+		fn()
+		return con
+	}
+	con.txHooks.onCommit(fn)
+	return con
+}
+
 // NewRecord check if value's primary key is blank
 func (con *DBCon) NewRecord(value interface{}) bool {
 	return con.NewScope(value).PrimaryKeyZero()
@@ -584,6 +891,25 @@ func (con *DBCon) RecordNotFound() bool {
 	return false
 }
 
+// Result returns the outcome of the operation which returned the connection. The result is a copy :
+// unlike the connection, it can be kept and handed over to other goroutines
+//     result := db.Create(&user).Result()
+//     fmt.Println(result.LastInsertID, result.RowsAffected, result.SQL)
+func (con *DBCon) Result() Result {
+	result := Result{
+		Error:        con.Error,
+		RowsAffected: con.RowsAffected,
+		LastInsertID: con.lastInsertID,
+	}
+	if con.executed != nil {
+		result.SQL = con.executed.SQL
+		if vars := con.loggedVars(con.executed); vars != nil {
+			result.Vars = append([]interface{}(nil), vars...)
+		}
+	}
+	return result
+}
+
 // CreateTable create table for models
 func (con *DBCon) CreateTable(models ...interface{}) *DBCon {
 	conn := con.Unscoped()
@@ -785,23 +1111,9 @@ func (con *DBCon) SetJoinTableHandler(source interface{}, column string, handler
 // AddError add error to the db
 func (con *DBCon) AddError(err error) error {
 	if err != nil {
+		err = con.translateError(err)
 		if err != ErrRecordNotFound {
-			switch con.logMode {
-			case LogVerbose:
-				con.log(err)
-			case LogOff:
-				if con.search != nil {
-					go con.toLog(strTagSql, fileWithLineNum(), time.Duration(0), con.search.SQL, err, con.search.SQLVars)
-				} else {
-					go con.toLog(fileWithLineNum(), err)
-				}
-			case LogDebug:
-				if con.search != nil {
-					con.toLog(strTagSql, fullFileWithLineNum(), time.Duration(0), con.search.SQL, err, con.search.SQLVars)
-				} else {
-					fmt.Printf("ERROR : %v\n%s\n", err, fullFileWithLineNum())
-				}
-			}
+			con.logError(err)
 			gormErrors := GormErrors(con.GetErrors())
 			gormErrors = gormErrors.Add(err)
 			if len(gormErrors.GetErrors()) > 1 {
@@ -824,8 +1136,9 @@ func (con *DBCon) GetErrors() []error {
 	return nil
 }
 
+// Log sends the values to the logger, at info level
 func (con *DBCon) Log(v ...interface{}) {
-	con.toLog(append([]interface{}{"info", fileWithLineNum()}, v...)...)
+	con.logger.Info(con.Context(), logMessage(v...))
 }
 
 // NewScope create a scope for current operation
@@ -871,12 +1184,22 @@ func (con *DBCon) emptyScope(value interface{}) *Scope {
 //doesn't clone extra informations
 func (con *DBCon) empty() *DBCon {
 	clone := DBCon{
-		sqli:     con.sqli,
-		parent:   con.parent,
-		logger:   con.logger,
-		logMode:  con.logMode,
-		settings: map[uint64]interface{}{},
-		Error:    con.Error,
+		sqli:          con.sqli,
+		parent:        con.parent,
+		logger:        con.logger,
+		logMode:       con.logMode,
+		slowThreshold: con.slowThreshold,
+		observer:      con.observer,
+		tracer:        con.tracer,
+		redaction:     con.redaction,
+		ctx:           con.ctx,
+		savePoint:     con.savePoint,
+		txOptions:     con.txOptions,
+		txHooks:       con.txHooks,
+		dryRun:        con.dryRun,
+		building:      con.building,
+		settings:      map[uint64]interface{}{},
+		Error:         con.Error,
 	}
 	return &clone
 }
@@ -912,24 +1235,92 @@ func (con DBCon) quote(name string) string {
 	return con.parent.quotedNames.get(name)
 }
 
-func (con *DBCon) toLog(v ...interface{}) {
-	con.logger.(logger).Print(v...)
+//maps the log mode onto the levels of the Logger
+func (con *DBCon) logLevel() LogLevel {
+	switch con.logMode {
+	case LogVerbose:
+		return LevelInfo
+	case LogDebug:
+		return LevelDebug
+	}
+	return LevelWarn
+}
+
+//driver errors become constraint errors, once
+func (con *DBCon) translateError(err error) error {
+	if con.parent == nil || con.parent.dialect == nil {
+		return err
+	}
+	if _, ok := err.(GormErrors); ok {
+		return err
+	}
+	var (
+		constraintErr *ConstraintError
+		statementErr  *StatementError
+	)
+	if errors.As(err, &constraintErr) || errors.As(err, &statementErr) {
+		return err
+	}
+	return con.parent.dialect.TranslateError(err)
 }
 
 func (con *DBCon) warnLog(v ...interface{}) {
 	if con != nil {
-		con.toLog(append([]interface{}{"warn", fileWithLineNum()}, v...)...)
+		con.logger.Warn(con.Context(), logMessage(v...))
 	} else {
 		fmt.Printf("Connection is NIL!")
 	}
 }
 
-func (con *DBCon) log(v ...interface{}) {
-	if con != nil {
-		con.toLog(append([]interface{}{"log", fileWithLineNum()}, v...)...)
+//on info level the statement was traced already, otherwise it goes along with the error
+func (con *DBCon) logError(err error) {
+	ctx := con.Context()
+	level := con.logLevel()
+	if level == LevelInfo || con.search == nil {
+		con.logger.Error(ctx, err.Error())
+	} else {
+		sql, vars := con.search.SQL, con.loggedVars(con.search)
+		con.logger.Trace(ctx, NowFunc(), func() (string, []interface{}) { return sql, vars }, 0, err)
+	}
+	if level == LevelDebug {
+		con.logger.Error(ctx, err.Error(), "stack", fullFileWithLineNum())
 	}
 }
 
-func (con *DBCon) slog(sql string, t time.Time, vars ...interface{}) {
-	con.toLog(strTagSql, fileWithLineNum(), NowFunc().Sub(t), sql, nil, vars)
+//the vars of the search, as they can be logged
+func (con *DBCon) loggedVars(search *Search) []interface{} {
+	switch con.redaction {
+	case RedactAll:
+		return nil
+	case RedactNone:
+		return search.SQLVars
+	}
+	if len(search.sensitiveVars) == 0 {
+		return search.SQLVars
+	}
+	result := append([]interface{}(nil), search.SQLVars...)
+	for _, index := range search.sensitiveVars {
+		if index < len(result) {
+			result[index] = redactedValue
+		}
+	}
+	return result
+}
+
+func (con *DBCon) slowLog(info *StatementInfo, search *Search) {
+	if con.slowThreshold <= 0 || info.Duration <= con.slowThreshold {
+		return
+	}
+	con.logger.Warn(con.Context(), "slow query",
+		"elapsed", info.Duration,
+		"threshold", con.slowThreshold,
+		"sql", info.SQL,
+		"vars", con.loggedVars(search),
+		"rows", info.RowsAffected,
+		"source", fileWithLineNum())
+}
+
+func (con *DBCon) slog(search *Search, t time.Time, rowsAffected int64) {
+	sql, vars := search.SQL, con.loggedVars(search)
+	con.logger.Trace(con.Context(), t, func() (string, []interface{}) { return sql, vars }, rowsAffected, nil)
 }
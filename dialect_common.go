@@ -25,6 +25,14 @@ const (
 	CommonHastableSql  = "SELECT count(*) FROM INFORMATION_SCHEMA.TABLES WHERE table_schema = ? AND table_name = ?"
 	CommonHascolumnSql = "SELECT count(*) FROM INFORMATION_SCHEMA.COLUMNS WHERE table_schema = ? AND table_name = ? AND column_name = ?"
 	CommonSelectDb     = "SELECT DATABASE()"
+
+	CommonSavePoint        = "SAVEPOINT %v"
+	CommonReleaseSavePoint = "RELEASE SAVEPOINT %v"
+	CommonRollbackTo       = "ROLLBACK TO SAVEPOINT %v"
+
+	CommonOnConflictDoNothing = "ON CONFLICT%v DO NOTHING"
+	CommonOnConflictDoUpdate  = "ON CONFLICT%v DO UPDATE SET %v"
+	CommonExcludedColumn      = "%v = excluded.%v"
 )
 
 func (commonDialect) GetName() string {
@@ -149,3 +157,44 @@ func (commonDialect) SelectFromDummyTable() string {
 func (commonDialect) LastInsertIDReturningSuffix(tableName, columnName string) string {
 	return ""
 }
+
+func (commonDialect) FirstInsertID(lastInsertID, rows int64) int64 {
+	return lastInsertID - rows + 1
+}
+
+func (commonDialect) AutoIncrementStepSQL() string {
+	return ""
+}
+
+//an update reports one affected row too
+func (commonDialect) UpsertInserted(rowsAffected int64) bool {
+	return false
+}
+
+//sqlite and postgres share the same ON CONFLICT syntax
+func (commonDialect) OnConflictSQL(conflictColumns, updateColumns []string) string {
+	target := ""
+	if len(conflictColumns) > 0 {
+		target = " (" + strings.Join(conflictColumns, ",") + ")"
+	}
+	if len(updateColumns) == 0 {
+		return fmt.Sprintf(CommonOnConflictDoNothing, target)
+	}
+	sets := make([]string, len(updateColumns))
+	for i, column := range updateColumns {
+		sets[i] = fmt.Sprintf(CommonExcludedColumn, column, column)
+	}
+	return fmt.Sprintf(CommonOnConflictDoUpdate, target, strings.Join(sets, ","))
+}
+
+//sqlite, mysql and postgres share the same savepoint syntax
+func (commonDialect) SavePointSQL(name string) (string, string, string) {
+	return fmt.Sprintf(CommonSavePoint, name),
+		fmt.Sprintf(CommonReleaseSavePoint, name),
+		fmt.Sprintf(CommonRollbackTo, name)
+}
+
+//the drivers of the other databases are not known
+func (commonDialect) TranslateError(err error) error {
+	return err
+}
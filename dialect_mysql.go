@@ -26,6 +26,19 @@ const (
 	MysqlHasForeignKey = "SELECT count(*) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS WHERE CONSTRAINT_SCHEMA=? AND TABLE_NAME=? AND CONSTRAINT_NAME=? AND CONSTRAINT_TYPE='FOREIGN KEY'"
 	MysqlDropIndex     = "DROP INDEX %v ON %v"
 	MysqlSelectDb      = "SELECT DATABASE()"
+	MysqlAutoIncStep   = "SELECT @@auto_increment_increment"
+
+	//numbers of the constraint violation errors
+	MysqlErrDuplicateEntry  = 1062
+	MysqlErrRowIsReferenced = 1451
+	MysqlErrNoReferencedRow = 1452
+	MysqlErrBadNull         = 1048
+	MysqlErrNoDefault       = 1364
+	MysqlErrCheckViolated   = 3819
+
+	MysqlOnDuplicateKey = "ON DUPLICATE KEY UPDATE %v"
+	MysqlValuesColumn   = "%v = VALUES(%v)"
+	MysqlSameColumn     = "%v = %v"
 )
 
 func (mysql) GetName() string {
@@ -140,6 +153,38 @@ func (mysql) SelectFromDummyTable() string {
 	return "FROM DUAL"
 }
 
+//mysql checks every unique index, so there is no conflict target. "Do nothing" sets a column to itself
+//(the primary key, when no conflict columns were given), because INSERT IGNORE would hide other errors too.
+//Without any column there is nothing to set, so the empty clause is reported as an error by the caller
+func (mysql) OnConflictSQL(conflictColumns, updateColumns []string) string {
+	if len(updateColumns) == 0 {
+		if len(conflictColumns) == 0 {
+			return ""
+		}
+		return fmt.Sprintf(MysqlOnDuplicateKey, fmt.Sprintf(MysqlSameColumn, conflictColumns[0], conflictColumns[0]))
+	}
+	sets := make([]string, len(updateColumns))
+	for i, column := range updateColumns {
+		sets[i] = fmt.Sprintf(MysqlValuesColumn, column, column)
+	}
+	return fmt.Sprintf(MysqlOnDuplicateKey, strings.Join(sets, ","))
+}
+
+//auto_increment_increment is usually more than 1 in multi-master setups (e.g. Galera)
+func (mysql) AutoIncrementStepSQL() string {
+	return MysqlAutoIncStep
+}
+
+//an update reports two affected rows, an update which changed nothing reports none
+func (mysql) UpsertInserted(rowsAffected int64) bool {
+	return rowsAffected == 1
+}
+
+//mysql reports the id of the first row inserted
+func (mysql) FirstInsertID(lastInsertID, rows int64) int64 {
+	return lastInsertID
+}
+
 func (m mysql) BuildForeignKeyName(tableName, field, dest string) string {
 	keyName := m.commonDialect.BuildForeignKeyName(tableName, field, dest)
 	if utf8.RuneCountInString(keyName) <= 64 {
@@ -157,3 +202,45 @@ func (m mysql) BuildForeignKeyName(tableName, field, dest string) string {
 
 	return fmt.Sprintf("%s%x", string(destRunes), bs)
 }
+
+// TranslateError reads the Number of the *mysql.MySQLError, the names are taken from its message
+func (mysql) TranslateError(err error) error {
+	number, ok := driverErrorField(err, "Number")
+	if !ok || number.Kind() != reflect.Uint16 {
+		return err
+	}
+	var (
+		result  = &ConstraintError{Err: err}
+		message = err.Error()
+	)
+	switch number.Uint() {
+	case MysqlErrDuplicateEntry:
+		result.Kind = ErrDuplicateKey
+		if match := regExpMysqlDuplicate.FindStringSubmatch(message); match != nil {
+			//mysql 8 prefixes the key with the table
+			if names := strings.SplitN(match[1], ".", 2); len(names) == 2 {
+				result.Table, result.Constraint = names[0], names[1]
+			} else {
+				result.Constraint = match[1]
+			}
+		}
+	case MysqlErrRowIsReferenced, MysqlErrNoReferencedRow:
+		result.Kind = ErrForeignKeyViolation
+		if match := regExpMysqlForeignKey.FindStringSubmatch(message); match != nil {
+			result.Table, result.Constraint, result.Column = match[1], match[2], match[3]
+		}
+	case MysqlErrBadNull, MysqlErrNoDefault:
+		result.Kind = ErrNotNullViolation
+		if match := regExpMysqlQuoted.FindStringSubmatch(message); match != nil {
+			result.Column = match[1]
+		}
+	case MysqlErrCheckViolated:
+		result.Kind = ErrCheckViolation
+		if match := regExpMysqlQuoted.FindStringSubmatch(message); match != nil {
+			result.Constraint = match[1]
+		}
+	default:
+		return err
+	}
+	return result
+}
@@ -27,6 +27,12 @@ const (
 	PgHastableSql  = "SELECT count(*) FROM INFORMATION_SCHEMA.tables WHERE table_name = $1 AND table_type = 'BASE TABLE'"
 	PgHascolumnSql = "SELECT count(*) FROM INFORMATION_SCHEMA.columns WHERE table_name = $1 AND column_name = $2"
 	PgCurrdbSql    = "SELECT CURRENT_DATABASE()"
+
+	//SQLSTATE codes of the constraint violations
+	PgUniqueViolation     = "23505"
+	PgForeignKeyViolation = "23503"
+	PgNotNullViolation    = "23502"
+	PgCheckViolation      = "23514"
 )
 
 func (postgres) GetName() string {
@@ -141,3 +147,24 @@ func (p postgres) LastInsertIDReturningSuffix(tableName, key string) string {
 func (postgres) SupportLastInsertID() bool {
 	return false
 }
+
+// TranslateError reads the SQLSTATE Code and the names of the *pq.Error (or of the *pgconn.PgError)
+func (postgres) TranslateError(err error) error {
+	result := &ConstraintError{Err: err}
+	switch driverErrorString(err, "Code") {
+	case PgUniqueViolation:
+		result.Kind = ErrDuplicateKey
+	case PgForeignKeyViolation:
+		result.Kind = ErrForeignKeyViolation
+	case PgNotNullViolation:
+		result.Kind = ErrNotNullViolation
+	case PgCheckViolation:
+		result.Kind = ErrCheckViolation
+	default:
+		return err
+	}
+	result.Table = driverErrorString(err, "Table", "TableName")
+	result.Column = driverErrorString(err, "Column", "ColumnName")
+	result.Constraint = driverErrorString(err, "Constraint", "ConstraintName")
+	return result
+}
@@ -22,6 +22,18 @@ const (
 	SqliteHasindexSql  = "SELECT count(*) FROM sqlite_master WHERE tbl_name = ? AND sql LIKE '%%INDEX %v ON%%'"
 	SqliteHastableSql  = "SELECT count(*) FROM sqlite_master WHERE type='table' AND name=?"
 	SqliteHascolumnSql = "SELECT count(*) FROM sqlite_master WHERE tbl_name = ? AND (sql LIKE '%%\"%v\" %%' OR sql LIKE '%%%v %%');\n"
+
+	//extended codes of the constraint violations (the ErrConstraint* of the driver)
+	SqliteConstraintCheck      = 275
+	SqliteConstraintForeignKey = 787
+	SqliteConstraintNotNull    = 1299
+	SqliteConstraintPrimaryKey = 1555
+	SqliteConstraintUnique     = 2067
+
+	//messages of the constraint violations
+	SqliteUniqueFailed  = "UNIQUE constraint failed: "
+	SqliteNotNullFailed = "NOT NULL constraint failed: "
+	SqliteCheckFailed   = "CHECK constraint failed: "
 )
 
 func (sqlite3) GetName() string {
@@ -126,3 +138,50 @@ func (s sqlite3) CurrentDatabase() (name string) {
 	}
 	return
 }
+
+// TranslateError reads the ExtendedCode of the sqlite3.Error. sqlite reports the names only in the message,
+// as "table.column" for unique and not null
+func (sqlite3) TranslateError(err error) error {
+	code, ok := driverErrorField(err, "ExtendedCode")
+	if !ok || code.Kind() != reflect.Int {
+		return err
+	}
+	var (
+		result  = &ConstraintError{Err: err}
+		message = err.Error()
+		names   string
+	)
+	switch code.Int() {
+	case SqliteConstraintUnique, SqliteConstraintPrimaryKey:
+		result.Kind = ErrDuplicateKey
+		names = messageAfter(message, SqliteUniqueFailed)
+	case SqliteConstraintNotNull:
+		result.Kind = ErrNotNullViolation
+		names = messageAfter(message, SqliteNotNullFailed)
+	case SqliteConstraintForeignKey:
+		result.Kind = ErrForeignKeyViolation
+		return result
+	case SqliteConstraintCheck:
+		result.Kind = ErrCheckViolation
+		result.Constraint = messageAfter(message, SqliteCheckFailed)
+		return result
+	default:
+		return err
+	}
+	//a composite index lists all its columns, the first one is kept
+	if i := strings.Index(names, ","); i >= 0 {
+		names = names[:i]
+	}
+	if tableAndColumn := strings.SplitN(names, ".", 2); len(tableAndColumn) == 2 {
+		result.Table, result.Column = tableAndColumn[0], tableAndColumn[1]
+	}
+	return result
+}
+
+//the part of the message following the prefix, empty if there is none
+func messageAfter(message, prefix string) string {
+	if i := strings.Index(message, prefix); i >= 0 {
+		return message[i+len(prefix):]
+	}
+	return ""
+}
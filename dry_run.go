@@ -0,0 +1,116 @@
+package gorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+)
+
+//nil safe, for the connections which are not in a DryRun session
+func (r *dryRunRecorder) record(search *Search) {
+	if r == nil {
+		return
+	}
+	r.l.Lock()
+	defer r.l.Unlock()
+	vars := make([]interface{}, len(search.SQLVars))
+	copy(vars, search.SQLVars)
+	r.statements = append(r.statements, Statement{SQL: search.SQL, Vars: vars})
+}
+
+func (r *dryRunRecorder) get() []Statement {
+	if r == nil {
+		return nil
+	}
+	r.l.Lock()
+	defer r.l.Unlock()
+	result := make([]Statement, len(r.statements))
+	copy(result, r.statements)
+	return result
+}
+
+func (dryRunConnector) Connect(context.Context) (driver.Conn, error) {
+	return dryRunConn{}, nil
+}
+
+func (dryRunConnector) Driver() driver.Driver {
+	return dryRunConnector{}
+}
+
+func (dryRunConnector) Open(string) (driver.Conn, error) {
+	return dryRunConn{}, nil
+}
+
+func (dryRunConn) Prepare(string) (driver.Stmt, error) {
+	return dryRunStmt{}, nil
+}
+
+func (dryRunConn) Close() error {
+	return nil
+}
+
+func (dryRunConn) Begin() (driver.Tx, error) {
+	return dryRunConn{}, nil
+}
+
+//accepts any isolation level and read only transactions
+func (dryRunConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return dryRunConn{}, nil
+}
+
+func (dryRunConn) Commit() error {
+	return nil
+}
+
+func (dryRunConn) Rollback() error {
+	return nil
+}
+
+//accepts any variable, as it is never sent anywhere
+func (dryRunConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (dryRunConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return dryRunResult{}, nil
+}
+
+func (dryRunConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return dryRunRows{}, nil
+}
+
+func (dryRunStmt) Close() error {
+	return nil
+}
+
+func (dryRunStmt) NumInput() int {
+	return -1
+}
+
+func (dryRunStmt) Exec([]driver.Value) (driver.Result, error) {
+	return dryRunResult{}, nil
+}
+
+func (dryRunStmt) Query([]driver.Value) (driver.Rows, error) {
+	return dryRunRows{}, nil
+}
+
+func (dryRunRows) Columns() []string {
+	return nil
+}
+
+func (dryRunRows) Close() error {
+	return nil
+}
+
+func (dryRunRows) Next([]driver.Value) error {
+	return io.EOF
+}
+
+func (dryRunResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (dryRunResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
@@ -1,9 +1,69 @@
 package gorm
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 )
 
+func (e *StatementError) Error() string {
+	if e.Table == "" {
+		return fmt.Sprintf(errStatement, e.Operation, e.Err)
+	}
+	return fmt.Sprintf(errStatement, e.Operation+" "+e.Table, e.Err)
+}
+
+// Unwrap returns the error of the driver, translated by the dialect
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the driver error
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports if target is the kind of the violation
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+//first error of the chain which has the struct field, for drivers which can't be imported
+func driverErrorField(err error, fieldName string) (reflect.Value, bool) {
+	for ; err != nil; err = unwrapError(err) {
+		value := reflect.Indirect(reflect.ValueOf(err))
+		if value.Kind() != reflect.Struct {
+			continue
+		}
+		if field := value.FieldByName(fieldName); field.IsValid() {
+			return field, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+//first string field of the chain with one of the names, empty if none
+func driverErrorString(err error, fieldNames ...string) string {
+	for _, fieldName := range fieldNames {
+		if field, ok := driverErrorField(err, fieldName); ok && field.Kind() == reflect.String {
+			return field.String()
+		}
+	}
+	return ""
+}
+
+func unwrapError(err error) error {
+	if wrapper, ok := err.(interface{ Unwrap() error }); ok {
+		return wrapper.Unwrap()
+	}
+	return nil
+}
+
 // GetErrors get all happened errors
 func (e GormErrors) GetErrors() []error {
 	return e
@@ -16,7 +76,7 @@ func (e GormErrors) Add(newErrors ...error) GormErrors {
 		} else {
 			ok = true
 			for _, e := range e {
-				if err == e {
+				if sameError(err, e) {
 					ok = false
 				}
 			}
@@ -28,6 +88,41 @@ func (e GormErrors) Add(newErrors ...error) GormErrors {
 	return e
 }
 
+//the same driver error is translated every time it's added
+func sameError(err, other error) bool {
+	if err == other {
+		return true
+	}
+	if statementErr, ok := err.(*StatementError); ok {
+		if otherStatementErr, ok := other.(*StatementError); ok {
+			return sameError(statementErr.Err, otherStatementErr.Err)
+		}
+	}
+	constraintErr, ok := err.(*ConstraintError)
+	otherConstraintErr, otherOk := other.(*ConstraintError)
+	return ok && otherOk && constraintErr.Err == otherConstraintErr.Err
+}
+
+// Is reports if any of the errors matches target
+func (e GormErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors which matches target, setting target to it
+func (e GormErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 // Add add an error
 func (e GormErrors) Error() string {
 	var errors []string
@@ -1,81 +1,193 @@
 package gorm
 
 import (
+	"context"
 	"database/sql/driver"
 	"fmt"
+	"os"
 	"reflect"
+	"strings"
 	"time"
 	"unicode"
 )
 
-// Print format & print log
-func (l Logger) Print(values ...interface{}) {
-	if len(values) > 1 {
-		currentTime := "\n\033[33m[" + NowFunc().Format("2006-01-02 15:04:05") + "]\033[0m"
-		source := fmt.Sprintf("\033[35m %v \033[0m", values[1])
-		messages := []interface{}{source, currentTime}
-
-		if values[0] == strTagSql {
-			//error
-			if values[4] != nil {
-				messages = append(messages, fmt.Sprintf("ERROR: %q\n", values[4]))
-			}
-			// duration
-			messages = append(messages, fmt.Sprintf("\033[36;1m[%.2fms]\033[0m", float64(values[2].(time.Duration).Nanoseconds()/1e4)/100.0))
-			// sql
-			var sql string
-			var formattedValues []string
-
-			for _, value := range values[5].([]interface{}) {
-				indirectValue := reflect.Indirect(reflect.ValueOf(value))
-				if indirectValue.IsValid() {
-					value = indirectValue.Interface()
-					switch typ := value.(type) {
-					case time.Time:
-						formattedValues = append(formattedValues, fmt.Sprintf("'%v'", typ.Format(time.RFC3339)))
-					case []byte:
-						str := string(typ)
-						//check if string is printable
-						isPrintable := true
-						for _, r := range str {
-							if !unicode.IsPrint(r) {
-								isPrintable = false
-								break //break this for
-							}
-						}
-						if isPrintable {
-							formattedValues = append(formattedValues, fmt.Sprintf("'%v'", str))
-						} else {
-							formattedValues = append(formattedValues, "'<binary>'")
-						}
-					case driver.Valuer:
-						if value, err := typ.Value(); err == nil && value != nil {
-							formattedValues = append(formattedValues, fmt.Sprintf("'%v'", value))
-						} else {
-							formattedValues = append(formattedValues, "NULL")
-						}
-					default:
-						formattedValues = append(formattedValues, fmt.Sprintf("'%v'", value))
+// NewLogger returns a logger writing lines to writer, colours are used only if colorful is set
+//     db.SetLogger(gorm.NewLogger(log.New(os.Stderr, "", log.LstdFlags), false))
+func NewLogger(writer LogWriter, colorful bool) Logger {
+	return StdLogger{LogWriter: writer, Colorful: colorful}
+}
+
+// Info logs a message, args are key value pairs
+func (l StdLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.print(colorInfo, "info", msg, args)
+}
+
+// Warn logs a warning, args are key value pairs
+func (l StdLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.print(colorWarn, "warn", msg, args)
+}
+
+// Error logs an error, args are key value pairs
+func (l StdLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.print(colorError, "error", msg, args)
+}
+
+// Trace logs an executed statement, with its duration, affected rows and error, if any
+func (l StdLogger) Trace(ctx context.Context, begin time.Time, sqlFunc func() (string, []interface{}), rowsAffected int64, err error) {
+	sql, vars := sqlFunc()
+	messages := []interface{}{l.paint(colorSource, fileWithLineNum()), l.paint(colorTime, "["+NowFunc().Format("2006-01-02 15:04:05")+"]")}
+	if err != nil {
+		messages = append(messages, l.paint(colorError, fmt.Sprintf("ERROR: %q", err)))
+	}
+	messages = append(messages,
+		l.paint(colorSQL, fmt.Sprintf("[%.2fms]", float64(NowFunc().Sub(begin).Nanoseconds()/1e4)/100.0)),
+		fmt.Sprintf("[rows:%d]", rowsAffected),
+		FormatSQL(sql, vars))
+	l.Println(messages...)
+}
+
+func (l StdLogger) print(color, tag, msg string, args []interface{}) {
+	source, ok := logArg(args, "source")
+	if !ok {
+		source = fileWithLineNum()
+	}
+	l.Println(
+		l.paint(colorSource, fmt.Sprint(source)),
+		l.paint(colorTime, "["+NowFunc().Format("2006-01-02 15:04:05")+"]"),
+		l.paint(color, "["+tag+"] "+msg+formatLogArgs(args)))
+}
+
+func (l StdLogger) paint(color, str string) string {
+	if l.Colorful {
+		return color + str + colorReset
+	}
+	return str
+}
+
+// Info logs a message at info level
+func (l SlogLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.InfoContext(ctx, msg, withSource(args)...)
+}
+
+// Warn logs a message at warn level
+func (l SlogLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.WarnContext(ctx, msg, withSource(args)...)
+}
+
+// Error logs a message at error level
+func (l SlogLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.ErrorContext(ctx, msg, withSource(args)...)
+}
+
+// Trace logs an executed statement at info level, or at error level if it failed
+func (l SlogLogger) Trace(ctx context.Context, begin time.Time, sqlFunc func() (string, []interface{}), rowsAffected int64, err error) {
+	sql, vars := sqlFunc()
+	args := []interface{}{
+		"sql", FormatSQL(sql, vars),
+		"elapsed", NowFunc().Sub(begin),
+		"rows", rowsAffected,
+		"source", fileWithLineNum(),
+	}
+	if err != nil {
+		l.ErrorContext(ctx, "statement", append(args, "error", err)...)
+		return
+	}
+	l.InfoContext(ctx, "statement", args...)
+}
+
+// FormatSQL returns the sql with the vars written in place of the placeholders, for logging only
+func FormatSQL(sql string, vars []interface{}) string {
+	var formattedValues []string
+
+	for _, value := range vars {
+		indirectValue := reflect.Indirect(reflect.ValueOf(value))
+		if indirectValue.IsValid() {
+			value = indirectValue.Interface()
+			switch typ := value.(type) {
+			case time.Time:
+				formattedValues = append(formattedValues, fmt.Sprintf("'%v'", typ.Format(time.RFC3339)))
+			case []byte:
+				str := string(typ)
+				//check if string is printable
+				isPrintable := true
+				for _, r := range str {
+					if !unicode.IsPrint(r) {
+						isPrintable = false
+						break //break this for
 					}
+				}
+				if isPrintable {
+					formattedValues = append(formattedValues, fmt.Sprintf("'%v'", str))
 				} else {
+					formattedValues = append(formattedValues, "'<binary>'")
+				}
+			case driver.Valuer:
+				if value, err := typ.Value(); err == nil && value != nil {
 					formattedValues = append(formattedValues, fmt.Sprintf("'%v'", value))
+				} else {
+					formattedValues = append(formattedValues, "NULL")
 				}
+			default:
+				formattedValues = append(formattedValues, fmt.Sprintf("'%v'", value))
 			}
+		} else {
+			formattedValues = append(formattedValues, fmt.Sprintf("'%v'", value))
+		}
+	}
 
-			var formattedValuesLength = len(formattedValues)
-			for index, value := range regExpLogger.Split(values[3].(string), -1) {
-				sql += value
-				if index < formattedValuesLength {
-					sql += formattedValues[index]
-				}
-			}
+	//placeholders without values are kept
+	index := 0
+	return regExpLogger.ReplaceAllStringFunc(sql, func(placeholder string) string {
+		index++
+		if index <= len(formattedValues) {
+			return formattedValues[index-1]
+		}
+		return placeholder
+	})
+}
 
-			messages = append(messages, sql)
+//key value pairs, as "key=value", the source is written in front of the line
+func formatLogArgs(args []interface{}) string {
+	var result string
+	for i := 0; i < len(args); i += 2 {
+		if args[i] == "source" {
+			continue
+		}
+		if i+1 < len(args) {
+			result += fmt.Sprintf(" %v=%v", args[i], args[i+1])
 		} else {
-			messages = append(messages, "\033[31;1m")
-			messages = append(messages, values[2:]...)
-			messages = append(messages, "\033[0m")
+			result += fmt.Sprintf(" %v", args[i])
 		}
-		l.Println(messages...)
 	}
+	return result
+}
+
+func logArg(args []interface{}, key string) (interface{}, bool) {
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == key {
+			return args[i+1], true
+		}
+	}
+	return nil, false
+}
+
+func withSource(args []interface{}) []interface{} {
+	if _, ok := logArg(args, "source"); ok {
+		return args
+	}
+	return append(args, "source", fileWithLineNum())
+}
+
+//joins the values the way Println does, without the new line
+func logMessage(v ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+}
+
+//colours only when writing to a terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
@@ -113,6 +113,25 @@ func (m *ModelStruct) Create(scope *Scope) {
 		}
 		//else - it's not an error : joins don't have primary key named id
 	}
+	m.softDelete = m.findSoftDelete()
+}
+
+//the column which marks the deleted rows : named by SoftDeleter, tagged `soft_delete` or the DeletedAt field
+func (m *ModelStruct) findSoftDelete() *softDeleteColumn {
+	if deleter, ok := reflect.New(m.ModelType).Interface().(SoftDeleter); ok {
+		column, kind := deleter.SoftDeleteColumn()
+		return &softDeleteColumn{column: column, kind: kind}
+	}
+	for _, field := range m.fieldsMap.fields {
+		if field.HasSetting(setSoftDelete) {
+			return &softDeleteColumn{column: field.DBName, kind: field.softDeleteKind()}
+		}
+	}
+	if m.HasColumn(FieldDeletedAt) {
+		field, _ := m.fieldsMap.get(FieldDeletedAt)
+		return &softDeleteColumn{column: field.DBName, kind: SoftDeleteTime}
+	}
+	return nil
 }
 
 func (m *ModelStruct) PKs() StructFields {
@@ -0,0 +1,37 @@
+package gorm
+
+//with a conflict target, the rows are read back after the upsert (nil safe)
+func (c *OnConflict) readBack() bool {
+	return c != nil && len(c.Columns) > 0
+}
+
+//the last insert id can be trusted only when every row was inserted : an upsert which updated a row
+//or did nothing reports the id of a previous insert. The rows affected by an update are told apart
+//only for single rows, by some dialects (nil safe)
+func (c *OnConflict) insertedAll(dialect Dialect, rowsAffected int64, rows int) bool {
+	switch {
+	case c == nil:
+		return true
+	case c.DoNothing:
+		return rowsAffected == int64(rows)
+	}
+	return rows == 1 && dialect.UpsertInserted(rowsAffected)
+}
+
+func (c *OnConflict) isTarget(field *StructField) bool {
+	return hasFieldName(c.Columns, field)
+}
+
+func (c *OnConflict) isExcepted(field *StructField) bool {
+	return hasFieldName(c.Except, field)
+}
+
+//names can be struct field names or column names
+func hasFieldName(names []string, field *StructField) bool {
+	for _, name := range names {
+		if name == field.StructName || name == field.DBName {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,9 @@
 package gorm
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -25,11 +27,17 @@ func (s *Scope) Err(err error) error {
 		//these are passed over to the con, so it can print what SQL was executing
 		s.con.search.SQL = s.Search.SQL
 		s.con.search.SQLVars = s.Search.SQLVars
+		s.con.search.sensitiveVars = s.Search.sensitiveVars
 		s.con.AddError(err)
 	}
 	return err
 }
 
+// Context returns the context the current operation runs with, so callbacks can honour cancellation
+func (s *Scope) Context() context.Context {
+	return s.con.Context()
+}
+
 // HasError check if there are any error
 func (s *Scope) HasError() bool {
 	return s.con.Error != nil
@@ -68,26 +76,54 @@ func (s *Scope) Fields() StructFields {
 
 // GetModelStruct get value's model struct, relationships based on struct and tag definition
 func (s *Scope) GetModelStruct() *ModelStruct {
-	var modelStruct ModelStruct
 	// Scope value can't be nil
 	if s.Value == nil {
-		return &modelStruct
+		return &ModelStruct{}
 	}
 	if s.rType.Kind() != reflect.Struct {
 		// Scope value need to be a struct
-		return &modelStruct
+		return &ModelStruct{}
 	}
 
 	// Get Cached model struct
-	if value := s.con.parent.modelsStructMap.get(s.rType); value != nil {
+	structs := s.con.parent.modelsStructMap
+	if value := structs.get(s.rType); value != nil {
 		return value
 	}
+	//related model structs, built along with the first one
+	if s.con.building != nil {
+		if value, ok := s.con.building[s.rType]; ok {
+			return value
+		}
+		return s.buildModelStruct()
+	}
 
+	structs.build.Lock()
+	defer structs.build.Unlock()
+	//built by someone else meanwhile
+	if value := structs.get(s.rType); value != nil {
+		return value
+	}
+	con := *s.con
+	con.building = make(map[reflect.Type]*ModelStruct)
+	builder := *s
+	builder.con = &con
+	result := builder.buildModelStruct()
+	//errors of the tags, kept by the caller's connection
+	s.con.Error = con.Error
+	//set cached ModelStructs, all at once
+	for modelType, value := range con.building {
+		structs.set(modelType, value)
+	}
+	return result
+}
+
+func (s *Scope) buildModelStruct() *ModelStruct {
+	var modelStruct ModelStruct
 	modelStruct.Create(s)
 
-	//set cached ModelStruc
-	s.con.parent.modelsStructMap.set(s.rType, &modelStruct)
-	// ATTN : first we add it to cache map, otherwise will infinite cycle
+	// ATTN : first we add it to building map, otherwise will infinite cycle
+	s.con.building[s.rType] = &modelStruct
 	// build relationships
 	modelStruct.processRelations(s)
 
@@ -130,6 +166,16 @@ func (s *Scope) PK() *StructField {
 	return nil
 }
 
+//the field used for optimistic locking, if any
+func (s *Scope) versionField() *StructField {
+	for _, field := range s.Fields() {
+		if field.IsVersion() && field.IsNormal() {
+			return field
+		}
+	}
+	return nil
+}
+
 //deprecated
 func (s *Scope) PrimaryKey() string {
 	return s.PKName()
@@ -143,6 +189,12 @@ func (s *Scope) PKName() string {
 	return ""
 }
 
+//the field named by the column (or struct field name) is tagged sensitive
+func (s *Scope) isSensitive(name string) bool {
+	field, ok := s.FieldByName(name)
+	return ok && field.IsSensitive()
+}
+
 // PrimaryKeyZero check main primary field's value is blank or not
 func (s *Scope) PrimaryKeyZero() bool {
 	field := s.PK()
@@ -235,6 +287,90 @@ func (s *Scope) Raw(sql string) *Scope {
 	return s
 }
 
+//notifies the observer about the statement about to be sent to the driver
+func (s *Scope) startStatement() *StatementInfo {
+	info := &StatementInfo{
+		Ctx:       s.Context(),
+		Operation: s.operation,
+		SQL:       s.Search.SQL,
+		Vars:      s.con.loggedVars(s.Search),
+		Begin:     NowFunc(),
+	}
+	if info.Operation == "" {
+		info.Operation = OperationRaw
+	}
+	info.Table = s.tracedTable()
+	_, info.span = s.con.tracer.StartSpan(info.Ctx, SpanStatement,
+		SpanAttribute{Key: "operation", Value: info.Operation},
+		SpanAttribute{Key: "table", Value: info.Table},
+		SpanAttribute{Key: "sql", Value: info.SQL})
+	if s.con.observer != nil {
+		s.con.observer.StatementStarted(info)
+	}
+	return info
+}
+
+func (s *Scope) finishStatement(info *StatementInfo, rowsAffected int64, err error) {
+	info.Duration = NowFunc().Sub(info.Begin)
+	info.RowsAffected = rowsAffected
+	info.Error = err
+	s.con.executed = &Search{SQL: info.SQL, SQLVars: s.Search.SQLVars, sensitiveVars: s.Search.sensitiveVars}
+	if s.con.observer != nil {
+		s.con.observer.StatementFinished(info)
+	}
+	info.span.SetAttributes(SpanAttribute{Key: "rows", Value: rowsAffected})
+	info.span.End(err)
+	s.con.slowLog(info, s.Search)
+}
+
+//starts a span, which is the parent of the spans started until the returned func ends it
+func (s *Scope) startSpan(name string, attributes ...SpanAttribute) func() {
+	previous := s.con.ctx
+	ctx, span := s.con.tracer.StartSpan(s.Context(), name, attributes...)
+	s.con.ctx = ctx
+	return func() {
+		span.End(s.con.Error)
+		s.con.ctx = previous
+	}
+}
+
+//starts the span of an operation
+func (s *Scope) startOperationSpan(name string) func() {
+	return s.startSpan(name, SpanAttribute{Key: "table", Value: s.tracedTable()})
+}
+
+//errors of the driver carry the statement which failed. sql.ErrNoRows is not a failure : it's returned as is,
+//so it can still be compared
+func (s *Scope) statementError(err error) error {
+	if err == nil || err == sql.ErrNoRows {
+		return err
+	}
+	var statementErr *StatementError
+	if errors.As(err, &statementErr) {
+		return err
+	}
+	operation := s.operation
+	if operation == "" {
+		operation = OperationRaw
+	}
+	return &StatementError{
+		SQL:       s.Search.SQL,
+		Vars:      s.con.loggedVars(s.Search),
+		Table:     s.tracedTable(),
+		Model:     s.GetModelStruct().ModelType,
+		Operation: operation,
+		Err:       s.con.translateError(err),
+	}
+}
+
+//raw statements might have no model
+func (s *Scope) tracedTable() string {
+	if s.Value != nil {
+		return s.TableName()
+	}
+	return s.Search.tableName
+}
+
 // Exec perform generated SQL
 func (s *Scope) Exec() *Scope {
 	//fail fast
@@ -242,7 +378,7 @@ func (s *Scope) Exec() *Scope {
 		return s
 	}
 	//avoid call if we don't need to
-	if s.con.logMode == LogVerbose || s.con.logMode == LogDebug {
+	if s.con.logLevel() >= LevelInfo {
 		defer s.trace(NowFunc())
 	}
 	s.Search.Exec(s)
@@ -372,7 +508,7 @@ func (s *Scope) scan(rows *sql.Rows, columns []string, fields StructFields) {
 		}
 	}
 
-	s.Err(rows.Scan(values...))
+	s.Err(s.statementError(rows.Scan(values...)))
 
 	for index, field := range resetFields {
 		if v := reflect.ValueOf(values[index]).Elem().Elem(); v.IsValid() {
@@ -383,26 +519,55 @@ func (s *Scope) scan(rows *sql.Rows, columns []string, fields StructFields) {
 
 func (s *Scope) row() *sql.Row {
 	//avoid call if we don't need to
-	if s.con.logMode == LogVerbose || s.con.logMode == LogDebug {
+	if s.con.logLevel() >= LevelInfo {
 		defer s.trace(NowFunc())
 	}
-	if s.con.parent.callbacks.rowQueries.len() > 0 {
-		s.callCallbacks(s.con.parent.callbacks.rowQueries)
-	}
-	s.Search.prepareQuerySQL(s)
+	s.prepareRowQuery()
 	return s.Search.QueryRow(s)
 }
 
 func (s *Scope) rows() (*sql.Rows, error) {
 	//avoid call if we don't need to
-	if s.con.logMode == LogVerbose || s.con.logMode == LogDebug {
+	if s.con.logLevel() >= LevelInfo {
+		defer s.trace(NowFunc())
+	}
+	s.prepareRowQuery()
+	return s.Search.Query(s)
+}
+
+//scans the single row into dest, the statement being finished with the error of Scan
+func (s *Scope) scanRow(dest ...interface{}) error {
+	//avoid call if we don't need to
+	if s.con.logLevel() >= LevelInfo {
 		defer s.trace(NowFunc())
 	}
+	s.prepareRowQuery()
+	row, info := s.Search.queryRow(s)
+	err := s.statementError(row.Scan(dest...))
+	switch err {
+	case nil:
+		s.finishStatement(info, 1, nil)
+	case sql.ErrNoRows:
+		s.finishStatement(info, 0, nil)
+	default:
+		s.finishStatement(info, 0, err)
+	}
+	return err
+}
+
+func (s *Scope) prepareRowQuery() {
+	s.operation = OperationRow
 	if s.con.parent.callbacks.rowQueries.len() > 0 {
 		s.callCallbacks(s.con.parent.callbacks.rowQueries)
 	}
 	s.Search.prepareQuerySQL(s)
-	return s.Search.Query(s)
+}
+
+//finishes a statement once its rows were read, with the error the rows report
+func (s *Scope) finishRows(info *StatementInfo, rows *sql.Rows, count int64) {
+	err := s.statementError(rows.Err())
+	s.Err(err)
+	s.finishStatement(info, count, err)
 }
 
 func (s *Scope) pluck(column string, value interface{}) *Scope {
@@ -413,14 +578,22 @@ func (s *Scope) pluck(column string, value interface{}) *Scope {
 		return s
 	}
 
-	rows, err := s.rows()
+	//avoid call if we don't need to
+	if s.con.logLevel() >= LevelInfo {
+		defer s.trace(NowFunc())
+	}
+	s.prepareRowQuery()
+	rows, info, err := s.Search.query(s)
 	if s.Err(err) == nil {
 		defer rows.Close()
+		var count int64
 		for rows.Next() {
+			count++
 			elem := reflect.New(dest.Type().Elem()).Interface()
-			s.Err(rows.Scan(elem))
+			s.Err(s.statementError(rows.Scan(elem)))
 			dest.Set(reflect.Append(dest, reflect.ValueOf(elem).Elem()))
 		}
+		s.finishRows(info, rows, count)
 	}
 	return s
 }
@@ -440,14 +613,14 @@ func (s *Scope) count(value interface{}) *Scope {
 		}
 	}
 	s.Search.setIsOrderIgnored()
-	s.Err(s.row().Scan(value))
+	s.Err(s.scanRow(value))
 	return s
 }
 
 // trace print sql log
 func (s *Scope) trace(t time.Time) {
 	if s.Search.SQL != "" {
-		s.con.slog(s.Search.SQL, t, s.Search.SQLVars...)
+		s.con.slog(s.Search, t, s.con.RowsAffected)
 	}
 }
 
@@ -463,6 +636,47 @@ func (s *Scope) shouldSaveAssociations() bool {
 	return !s.HasError()
 }
 
+//reads the "gorm:skip_transaction" setting : `true` (or "skip") writes without a transaction,
+//"auto" opens one only when associations will be saved along with the value. It's decided by the first step,
+//before the Before... methods run (they can write with the transaction), so the associations they add don't count
+func (s *Scope) needsTransaction() bool {
+	if skipTx, ok := s.Get(gormSettingSkipTx); ok {
+		if v, ok := skipTx.(bool); ok && v {
+			return false
+		}
+		if v, ok := skipTx.(string); ok {
+			switch v {
+			case "skip", "true":
+				return false
+			case "auto":
+				return s.hasAssociationsToSave()
+			}
+		}
+	}
+	return true
+}
+
+func (s *Scope) hasAssociationsToSave() bool {
+	if s.Value == nil || !s.shouldSaveAssociations() {
+		return false
+	}
+	if s.rValue.Kind() == reflect.Slice {
+		for i := 0; i < s.rValue.Len(); i++ {
+			if s.elementScope(i).hasAssociationsToSave() {
+				return true
+			}
+		}
+		return false
+	}
+	//throw away scope : the fields are read again after the Before... methods
+	for _, field := range s.con.emptyScope(s.Value).Fields() {
+		if s.willSaveFieldAssociations(field) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Scope) quoteIfPossible(str string) string {
 	// only match string like `name`, `users.name`
 	if regExpNameMatcher.MatchString(str) {
@@ -527,14 +741,14 @@ func (s *Scope) related(value interface{}, foreignKeys ...string) *Scope {
 				continue
 			}
 			aStr := fmt.Sprintf("%v = ?", s.con.quote(toField.DBName))
-			s.Err(tx.Where(aStr, s.PrimaryKeyValue()).Find(value).Error)
+			s.Err(tx.Where(aStr, markSensitive(s.PrimaryKeyValue(), s.isSensitive(s.PKName()))).Find(value).Error)
 			return s
 		}
 
 		//fail fast - relationship is nil
 		if !fromField.HasRelations() {
 			aStr := fmt.Sprintf("%v = ?", s.con.quote(toScope.PKName()))
-			s.Err(tx.Where(aStr, fromField.Value.Interface()).Find(value).Error)
+			s.Err(tx.Where(aStr, fromField.varValue()).Find(value).Error)
 			return s
 		}
 		var (
@@ -562,7 +776,7 @@ func (s *Scope) related(value interface{}, foreignKeys ...string) *Scope {
 							"%v = ?",
 							s.con.quote(AssociationForeignDBNames[idx]),
 						),
-						field.Value.Interface(),
+						field.varValue(),
 					)
 				}
 			}
@@ -576,7 +790,7 @@ func (s *Scope) related(value interface{}, foreignKeys ...string) *Scope {
 							"%v = ?",
 							s.con.quote(foreignKey),
 						),
-						field.Value.Interface(),
+						field.varValue(),
 					)
 				}
 			}
@@ -622,6 +836,9 @@ func (s *Scope) willSaveFieldAssociations(field *StructField) bool {
 
 func (s *Scope) callCallbacks(funcs ScopedFuncs) *Scope {
 	for _, f := range funcs {
+		if s.skipLeft {
+			break
+		}
 		//was (*f)(s) - but IDE went balistic
 		rf := *f
 		rf(s)
@@ -629,11 +846,624 @@ func (s *Scope) callCallbacks(funcs ScopedFuncs) *Scope {
 	return s
 }
 
-//calls methods after query
+//runs the query callbacks, `dest` (if not nil) receives the rows instead of the value
 func (s *Scope) postQuery(dest interface{}) *Scope {
-	//Was "queryCallback"
+	s.operation = OperationQuery
+	s.queryDest = dest
+	return s.callCallbacks(s.con.parent.callbacks.queries)
+}
+
+//like postQuery, but every row is scanned into a new value and handed to fc, instead of being appended to a slice
+func (s *Scope) iterate(fc reflect.Value) *Scope {
+	s.operation = OperationQuery
+	if fc.Kind() != reflect.Func || fc.Type().NumIn() != 1 || fc.Type().NumOut() != 1 ||
+		fc.Type().In(0) != reflect.PtrTo(s.rType) || fc.Type().Out(0) != errorType {
+		s.Err(fmt.Errorf(errIterateFunc, s.rType))
+		return s
+	}
+
+	//avoid call if we don't need to
+	if s.con.logLevel() >= LevelInfo {
+		defer s.trace(NowFunc())
+	}
+
+	s.Search.prepareQuerySQL(s)
+	if s.HasError() {
+		return s
+	}
+	s.con.RowsAffected = 0
+	if str, ok := s.Get(gormSettingQueryOpt); ok {
+		s.Search.SQL += addExtraSpaceIfExist(fmt.Sprint(str))
+	}
+
+	rows, info, err := s.Search.query(s)
+	if s.Err(err) != nil {
+		return s
+	}
+	defer rows.Close()
+
+	columns, _ := rows.Columns()
+	for rows.Next() && !s.HasError() {
+		s.con.RowsAffected++
+
+		elem := reflect.New(s.rType)
+		elemScope := &Scope{
+			con:    s.con,
+			Search: s.Search.clone(elem.Interface()),
+			Value:  elem.Interface(),
+			rValue: elem.Elem(),
+			rType:  s.rType,
+		}
+		s.scan(rows, columns, elemScope.Fields())
+
+		//the row goes through the query callbacks placed after "gorm:query" (preload, after find...)
+		if !s.HasError() {
+			elemScope.callCallbacks(s.con.parent.callbacks.readRows)
+		}
+		if !s.HasError() {
+			if result := fc.Call([]reflect.Value{elem})[0]; !result.IsNil() {
+				s.Err(result.Interface().(error))
+			}
+		}
+	}
+	s.finishRows(info, rows, s.con.RowsAffected)
+
+	return s
+}
+
+//builds the condition for the rows after the `last` one, in primary keys order :
+//(a > ?) OR (a = ? AND b > ?) OR ...
+func (s *Scope) keysetCondition(last *Scope) (string, []interface{}) {
+	var (
+		sql, equals     string
+		args, eqArgs    []interface{}
+		quotedTableName = s.quotedTableName()
+	)
+	for _, pk := range last.PKs() {
+		column := fmt.Sprintf("%v.%v", quotedTableName, s.con.quote(pk.DBName))
+		if sql != "" {
+			sql += " OR "
+		}
+		sql += fmt.Sprintf("(%v%v > ?)", equals, column)
+		args = append(append(args, eqArgs...), pk.varValue())
+
+		equals += fmt.Sprintf("%v = ? AND ", column)
+		eqArgs = append(eqArgs, pk.varValue())
+	}
+	return sql, args
+}
+
+func (s *Scope) skipAfterFind() bool {
+	if skip, ok := s.Get(gormSettingSkipAfterFind); ok {
+		if v, ok := skip.(bool); ok {
+			return v
+		}
+	}
+	return false
+}
+
+//runs the create callbacks. The elements of a slice are inserted with one statement for every `batchSize` of them
+//(zero means all of them)
+func (s *Scope) postCreate() *Scope {
+	s.operation = OperationCreate
+	if s.rValue.Kind() == reflect.Slice {
+		if elemType := s.rValue.Type().Elem(); elemType.Kind() != reflect.Struct &&
+			(elemType.Kind() != reflect.Ptr || elemType.Elem().Kind() != reflect.Struct) {
+			s.Err(fmt.Errorf(errCreateSlice, s.rValue.Type()))
+			return s
+		}
+		s.elements = make([]*Scope, 0, s.rValue.Len())
+		for i := 0; i < s.rValue.Len(); i++ {
+			s.elements = append(s.elements, s.elementScope(i))
+		}
+	}
+	return s.callCallbacks(s.con.parent.callbacks.creates)
+}
+
+//one INSERT with multiple VALUES for every batch of elements. Consecutive elements which have the same columns to
+//insert are grouped together
+func (s *Scope) insertElements() {
+	var (
+		columns      []string
+		values       [][]interface{}
+		extraOption  string
+		rowsAffected int64
+	)
+
+	for _, elem := range s.elements {
+		elem.initVersion()
+		elemColumns, elemValues, blankColumns := elem.insertColumns()
+		elem.blankColumns = blankColumns
+		columns = append(columns, elemColumns)
+		values = append(values, elemValues)
+	}
+
+	if str, ok := s.Get(gormSettingInsertOpt); ok {
+		extraOption = fmt.Sprint(str)
+	}
+
+	for from := 0; from < len(s.elements) && !s.HasError(); {
+		//there is no DEFAULT VALUES for multiple rows, so those elements are inserted one by one
+		to := from + 1
+		for columns[from] != "" && to < len(s.elements) && columns[to] == columns[from] && (s.batchSize <= 0 || to-from < s.batchSize) {
+			to++
+		}
+		rowsAffected += s.insertBatch(s.elements[from:to], columns[from], values[from:to], extraOption)
+		from = to
+	}
+	s.con.RowsAffected = rowsAffected
+}
+
+//executes one INSERT for the elements (which have the same columns) and fills back their primary keys.
+//Returns the number of inserted rows
+func (s *Scope) insertBatch(elements []*Scope, columns string, values [][]interface{}, extraOption string) int64 {
+	var (
+		dialect         = s.con.parent.dialect
+		quotedTableName = s.quotedTableName()
+		primaryField    = elements[0].PK()
+		returningColumn = strEverything
+		rows            string
+	)
+
+	s.Search.SQLVars = nil
+	s.Search.sensitiveVars = nil
+	for _, row := range values {
+		placeholders := ""
+		for _, value := range row {
+			if placeholders != "" {
+				placeholders += ","
+			}
+			placeholders += s.Search.addToVars(value, dialect)
+		}
+		if rows != "" {
+			rows += ","
+		}
+		rows += "(" + placeholders + ")"
+	}
+
+	if primaryField != nil {
+		returningColumn = s.con.quote(primaryField.DBName)
+	}
+	lastInsertIDReturningSuffix := dialect.LastInsertIDReturningSuffix(quotedTableName, returningColumn)
+	onConflict, conflict := elements[0].onConflictSQL()
+	if s.HasError() {
+		return 0
+	}
+	if conflict.readBack() {
+		lastInsertIDReturningSuffix = ""
+	}
+
+	if columns == "" {
+		s.Raw(fmt.Sprintf(
+			"INSERT INTO %v DEFAULT VALUES%v%v%v",
+			quotedTableName,
+			addExtraSpaceIfExist(onConflict),
+			addExtraSpaceIfExist(extraOption),
+			addExtraSpaceIfExist(lastInsertIDReturningSuffix),
+		))
+	} else {
+		s.Raw(fmt.Sprintf(
+			"INSERT INTO %v (%v) VALUES %v%v%v%v",
+			quotedTableName,
+			columns,
+			rows,
+			addExtraSpaceIfExist(onConflict),
+			addExtraSpaceIfExist(extraOption),
+			addExtraSpaceIfExist(lastInsertIDReturningSuffix),
+		))
+	}
+
+	//avoid call if we don't need to
+	if s.con.logLevel() >= LevelInfo {
+		defer s.trace(NowFunc())
+	}
+
+	var rowsAffected int64
+	if lastInsertIDReturningSuffix == "" || primaryField == nil {
+		if execResult, err := s.Search.Exec(s); s.Err(err) == nil {
+			rowsAffected = s.con.RowsAffected
+			// set primary values to primary fields : the ids of a multiple rows insert are consecutive
+			if conflict.readBack() {
+				for _, elem := range elements {
+					elem.reloadUpserted(conflict)
+				}
+			} else if conflict.insertedAll(dialect, rowsAffected, len(elements)) && primaryField != nil && primaryField.IsBlank() {
+				if lastInsertID, err := execResult.LastInsertId(); s.Err(err) == nil {
+					//the ids are left blank when the step can't be read
+					if step := s.autoIncrementStep(); step > 0 {
+						firstInsertID := dialect.FirstInsertID(lastInsertID, int64(len(elements)))
+						for i, elem := range elements {
+							s.Err(elem.PK().Set(firstInsertID + int64(i)*step))
+						}
+					}
+					s.con.lastInsertID = lastInsertID
+				}
+			}
+		}
+	} else {
+		//rows are returned in the order of the VALUES, but an upsert which does nothing skips rows
+		if result, info, err := s.Search.query(s); s.Err(err) == nil {
+			defer result.Close()
+			var ids []interface{}
+			for result.Next() {
+				id := reflect.New(primaryField.Value.Type())
+				if s.Err(s.statementError(result.Scan(id.Interface()))) == nil {
+					ids = append(ids, id.Elem().Interface())
+				}
+			}
+			s.finishRows(info, result, int64(len(ids)))
+			rowsAffected = int64(len(ids))
+			if s.con.dryRun != nil {
+				//a dry run returns no rows : the elements are reported as inserted, without primary keys
+				rowsAffected = int64(len(elements))
+			} else {
+				s.setReturnedIds(elements, ids)
+			}
+			if len(ids) > 0 {
+				s.con.lastInsertID = intValue(reflect.ValueOf(ids[len(ids)-1]))
+			}
+		}
+	}
+	return rowsAffected
+}
+
+//the increment between the ids of the rows inserted by one statement, read on the connection of the statement.
+//Returns 0 when it can't be read
+func (s *Scope) autoIncrementStep() int64 {
+	step := int64(1)
+	if query := s.con.parent.dialect.AutoIncrementStepSQL(); query != "" {
+		if err := s.con.sqli.QueryRowContext(s.Context(), query).Scan(&step); err != nil {
+			return 0
+		}
+	}
+	return step
+}
+
+//sets the ids returned by a multiple rows insert. An upsert which did nothing skips the elements having a primary key
+//which conflicted (the elements without one are always inserted), so the rows are matched in order by primary key
+func (s *Scope) setReturnedIds(elements []*Scope, ids []interface{}) {
+	if len(ids) == len(elements) {
+		for i, elem := range elements {
+			s.Err(elem.PK().Set(ids[i]))
+		}
+		return
+	}
+	var (
+		unknown []int
+		next    int
+	)
+	for i, elem := range elements {
+		field := elem.PK()
+		if !field.IsBlank() {
+			if next < len(ids) && reflect.DeepEqual(ids[next], field.Value.Interface()) {
+				next++
+			}
+			continue
+		}
+		if next < len(ids) {
+			s.Err(field.Set(ids[next]))
+			next++
+			continue
+		}
+		unknown = append(unknown, i)
+	}
+	if len(unknown) > 0 || next < len(ids) {
+		s.Err(fmt.Errorf(errReturnedIds, len(ids), len(elements), s.TableName(), unknown))
+	}
+}
+
+//collects the quoted columns to be inserted with their values, along with the blank columns which have default values
+func (s *Scope) insertColumns() (string, []interface{}, string) {
+	var (
+		columns, blankColumnsWithDefaultValue string
+		values                                []interface{}
+	)
+	for _, field := range s.Fields() {
+		if !s.Search.changeableField(field) {
+			continue
+		}
+
+		if field.IsNormal() {
+			isBlankWithDefaultValue := field.IsBlank() && field.HasDefaultValue()
+			isNotPKOrBlank := !field.IsPrimaryKey() || !field.IsBlank()
+			if isBlankWithDefaultValue {
+				if blankColumnsWithDefaultValue != "" {
+					blankColumnsWithDefaultValue += ","
+				}
+				blankColumnsWithDefaultValue += s.con.quote(field.DBName)
+			} else if isNotPKOrBlank {
+				if columns != "" {
+					columns += ","
+				}
+				columns += s.con.quote(field.DBName)
+				values = append(values, field.varValue())
+			}
+		} else {
+			if field.HasRelations() && field.RelationIsBelongsTo() {
+				ForeignDBNames := field.GetForeignDBNames()
+				for _, foreignKey := range ForeignDBNames {
+					foreignField, ok := s.FieldByName(foreignKey)
+					if ok && !s.Search.changeableField(foreignField) {
+						if columns != "" {
+							columns += ","
+						}
+						columns += s.con.quote(foreignField.DBName)
+						values = append(values, foreignField.varValue())
+					}
+				}
+			}
+		}
+	}
+	return columns, values, blankColumnsWithDefaultValue
+}
+
+//renders the upsert clause, when it was requested with `DBCon.OnConflict`
+func (s *Scope) onConflictSQL() (string, *OnConflict) {
+	setting, ok := s.Get(gormSettingOnConflict)
+	if !ok {
+		return "", nil
+	}
+	conflict, ok := setting.(*OnConflict)
+	if !ok {
+		return "", nil
+	}
+
+	var targetColumns, updateColumns []string
+	for _, column := range conflict.Columns {
+		if field, ok := s.FieldByName(column); ok {
+			column = field.DBName
+		}
+		targetColumns = append(targetColumns, s.con.quote(column))
+	}
+	if len(targetColumns) == 0 {
+		for _, field := range s.PKs() {
+			targetColumns = append(targetColumns, s.con.quote(field.DBName))
+		}
+	}
+
+	if !conflict.DoNothing {
+		if conflict.UpdateAll {
+			for _, field := range s.Fields() {
+				if !field.IsNormal() || field.IsPrimaryKey() || !s.Search.changeableField(field) ||
+					conflict.isTarget(field) || conflict.isExcepted(field) {
+					continue
+				}
+				updateColumns = append(updateColumns, s.con.quote(field.DBName))
+			}
+		} else {
+			for _, column := range conflict.UpdateColumns {
+				if field, ok := s.FieldByName(column); ok {
+					column = field.DBName
+				}
+				updateColumns = append(updateColumns, s.con.quote(column))
+			}
+		}
+	}
+
+	clause := s.con.parent.dialect.OnConflictSQL(targetColumns, updateColumns)
+	if clause == "" {
+		//mysql has no upsert without a key to update
+		s.Err(fmt.Errorf(errOnConflictTarget, s.TableName()))
+	}
+	return clause, conflict
+}
+
+//reads back the row by the conflict target : after an upsert, the id reported by the database
+//might belong to another row (or to no row at all)
+func (s *Scope) reloadUpserted(conflict *OnConflict) {
+	db := s.con.empty().Unscoped().Table(s.TableName())
+	for _, column := range conflict.Columns {
+		field, ok := s.FieldByName(column)
+		if !ok {
+			s.Err(fmt.Errorf(errFieldNotFound, column, s.TableName()))
+			return
+		}
+		db = db.Where(fmt.Sprintf("%v = ?", s.con.quote(field.DBName)), field.varValue())
+	}
+	if s.Err(db.Scan(s.Value).Error) == nil {
+		for _, field := range s.PKs() {
+			if !IsZero(field.Value) {
+				field.UnsetIsBlank()
+			}
+		}
+	}
+}
+
+//reads back the columns which were filled by the database defaults
+func (s *Scope) reloadColumns(columns string) {
+	db := s.con.empty().Table(s.TableName()).Select(columns)
+	for _, field := range s.Fields() {
+		if field.IsPrimaryKey() && !field.IsBlank() {
+			db = db.Where(fmt.Sprintf("%v = ?", field.DBName), field.varValue())
+		}
+	}
+
+	db.Scan(s.Value)
+}
+
+//scope for an element of the slice value, sharing the connection (and the transaction) of the slice scope
+func (s *Scope) elementScope(index int) *Scope {
+	elem := s.rValue.Index(index)
+	if elem.Kind() != reflect.Ptr {
+		elem = elem.Addr()
+	}
+	value := elem.Interface()
+	return &Scope{
+		con:    s.con,
+		Search: s.Search.clone(value),
+		Value:  value,
+		rValue: IndirectValue(value),
+		rType:  GetType(value),
+	}
+}
+
+//runs the update callbacks, with the attributes to update (nil updates every changeable field)
+func (s *Scope) postUpdate(attrs interface{}) *Scope {
+	s.operation = OperationUpdate
+	s.updateAttrs = attrs
+	return s.callCallbacks(s.con.parent.callbacks.updates)
+}
+
+//optimistic locking : the version is incremented by the update and, for a value with primary key, checked against
+//the one it was read with. Returns the version field and its previous value, only if it's checked
+func (s *Scope) lockVersion() (*StructField, int64) {
+	if _, ok := s.Get(gormSettingUpdateColumn); ok {
+		return nil, 0
+	}
+	version := s.versionField()
+	if version == nil {
+		return nil, 0
+	}
+	if _, ok := s.updateMaps[version.DBName]; ok {
+		//set by the caller
+		return nil, 0
+	}
+	quotedColumn := s.con.quote(version.DBName)
+	if s.PrimaryKeyZero() {
+		if s.updateMaps != nil {
+			s.updateMaps[version.DBName] = SqlExpr(quotedColumn + " + 1")
+		}
+		return nil, 0
+	}
+	current := intValue(reflect.Indirect(version.Value))
+	s.Search.Where(fmt.Sprintf("%v.%v = ?", s.quotedTableName(), quotedColumn), markSensitive(current, version.IsSensitive()))
+	s.Err(s.SetColumn(version, current+1))
+	return version, current
+}
+
+//optimistic locking starts with the first version
+func (s *Scope) initVersion() {
+	if version := s.versionField(); version != nil && version.IsBlank() {
+		s.Err(s.SetColumn(version, 1))
+	}
+}
+
+//runs the delete callbacks
+func (s *Scope) postDelete() *Scope {
+	s.operation = OperationDelete
+	return s.callCallbacks(s.con.parent.callbacks.deletes)
+}
+
+//[delete step 3] deletes (or soft deletes) the rows of the has one and has many relations tagged `cascade:delete`
+//or selected, along with the join rows of the many to many ones. Only the rows of values with primary key cascade.
+//The related rows go first, so the foreign keys allow the delete
+func (s *Scope) deleteAssociationsCallback() {
+	if s.HasError() {
+		return
+	}
+	for _, field := range s.Fields() {
+		if !field.HasRelations() || field.RelKind() == relBelongsTo {
+			continue
+		}
+		if !field.cascadesDelete() && !s.Search.checkFieldIncluded(field) {
+			continue
+		}
+		endSpan := s.startSpan(SpanAssociation, SpanAttribute{Key: "field", Value: field.StructName})
+		s.deleteRelated(field)
+		endSpan()
+		if s.HasError() {
+			return
+		}
+	}
+}
+
+func (s *Scope) deleteRelated(field *StructField) {
+	var (
+		conn             = s.con.empty()
+		ForeignDBNames   = field.GetForeignDBNames()
+		sourceFieldNames StrSlice
+	)
+	if s.Search.isUnscoped() {
+		conn = conn.Unscoped()
+	}
+
+	if field.RelKind() == relMany2many {
+		for _, dbName := range field.GetForeignFieldNames() {
+			if f, ok := s.FieldByName(dbName); ok {
+				sourceFieldNames.add(f.StructName)
+			}
+		}
+	} else {
+		for _, name := range field.GetAssociationForeignFieldNames() {
+			if f, ok := s.FieldByName(name); ok {
+				sourceFieldNames.add(f.StructName)
+			}
+		}
+	}
+	sourceKeys := s.getColumnAsArray(sourceFieldNames)
+	if len(sourceKeys) == 0 {
+		return
+	}
+	related := conn.Where(
+		fmt.Sprintf("%v IN (%v)", s.toQueryCondition(ForeignDBNames), toQueryMarks(sourceKeys)),
+		toQueryValues(sourceKeys)...,
+	)
+
+	if field.RelKind() == relMany2many {
+		//a soft deleted row keeps its join rows, so it's restored with them
+		if s.GetModelStruct().softDelete != nil && !s.Search.isUnscoped() {
+			return
+		}
+		joinTableHandler := field.JoinHandler()
+		s.Err(joinTableHandler.Delete(joinTableHandler, related))
+		return
+	}
+
+	// Polymorphic Relations
+	if field.HasSetting(setPolymorphicDbname) {
+		related = related.Where(
+			fmt.Sprintf("%v = ?", conn.quote(field.GetStrSetting(setPolymorphicDbname))),
+			field.GetStrSetting(setPolymorphicValue))
+	}
+	//one by one, so their hooks are called and their own relations cascade
+	rows := reflect.New(reflect.SliceOf(reflect.PtrTo(field.Type)))
+	if s.Err(related.Find(rows.Interface()).Error) != nil {
+		return
+	}
+	for i := 0; i < rows.Elem().Len(); i++ {
+		if s.Err(conn.Delete(rows.Elem().Index(i).Interface()).Error) != nil {
+			return
+		}
+	}
+}
+
+//runs the restore callbacks, which undelete soft deleted rows
+func (s *Scope) postRestore() *Scope {
+	s.operation = OperationUpdate
+	if s.GetModelStruct().softDelete == nil {
+		s.Err(fmt.Errorf(errNoSoftDelete, s.GetModelStruct().ModelType))
+		return s
+	}
+	return s.callCallbacks(s.con.parent.callbacks.restores)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// internal callbacks functions
+////////////////////////////////////////////////////////////////////////////////
+//calls fn with the scope or, for a slice being created, with the scopes of its elements until an error happens
+func (s *Scope) eachElement(fn func(*Scope)) {
+	if s.elements == nil {
+		fn(s)
+		return
+	}
+	for _, elem := range s.elements {
+		if s.HasError() {
+			return
+		}
+		fn(elem)
+	}
+}
+
+//[query step 1] was "queryCallback"
+func (s *Scope) selectRowsCallback() {
+	if s.iterator != nil {
+		s.iterate(*s.iterator)
+		//every row went through the callbacks left
+		s.skipLeft = true
+		return
+	}
 	//avoid call if we don't need to
-	if s.con.logMode == LogVerbose || s.con.logMode == LogDebug {
+	if s.con.logLevel() >= LevelInfo {
 		defer s.trace(NowFunc())
 	}
 	var (
@@ -642,8 +1472,8 @@ func (s *Scope) postQuery(dest interface{}) *Scope {
 		queryResults    = s.rValue
 	)
 
-	if dest != nil {
-		queryResults = reflect.Indirect(reflect.ValueOf(dest))
+	if s.queryDest != nil {
+		queryResults = reflect.Indirect(reflect.ValueOf(s.queryDest))
 	}
 	switch queryResults.Kind() {
 	case reflect.Slice:
@@ -658,7 +1488,7 @@ func (s *Scope) postQuery(dest interface{}) *Scope {
 	case reflect.Struct:
 	default:
 		s.Err(fmt.Errorf("SCOPE : unsupported destination, should be slice or struct : %v", s))
-		return s
+		return
 	}
 
 	s.Search.prepareQuerySQL(s)
@@ -669,7 +1499,7 @@ func (s *Scope) postQuery(dest interface{}) *Scope {
 			s.Search.SQL += addExtraSpaceIfExist(fmt.Sprint(str))
 		}
 
-		if rows, err := s.Search.Query(s); s.Err(err) == nil {
+		if rows, info, err := s.Search.query(s); s.Err(err) == nil {
 			defer rows.Close()
 
 			columns, _ := rows.Columns()
@@ -691,384 +1521,435 @@ func (s *Scope) postQuery(dest interface{}) *Scope {
 					}
 				}
 			}
+			s.finishRows(info, rows, s.con.RowsAffected)
 
 			if s.con.RowsAffected == 0 && !isSlice {
 				s.Err(ErrRecordNotFound)
 			}
 		}
 	}
-	//END Was "queryCallback"
+}
 
+//[query step 2] while iterating, it's called for every row
+func (s *Scope) preloadCallback() {
 	if s.Search.hasPreload() && !s.HasError() {
 		s.Search.doPreload(s)
 	}
+}
 
-	if !s.HasError() {
+//[query step 3] while iterating, it's called for every row
+func (s *Scope) afterQueryCallback() {
+	if !s.HasError() && !s.skipAfterFind() {
 		s.CallMethod(methAfterFind)
 	}
+}
 
-	return s
+//[create step 2]
+func (s *Scope) beforeCreateCallback() {
+	s.eachElement(func(elem *Scope) {
+		if !elem.HasError() {
+			elem.CallMethod(methBeforeSave)
+		}
+		if !elem.HasError() {
+			elem.CallMethod(methBeforeCreate)
+		}
+	})
 }
 
-//calls methods after creation
-func (s *Scope) postCreate() *Scope {
-	//begin transaction
-	result, txStarted := s.begin()
+//[create step 4]
+func (s *Scope) updateTimeStampForCreateCallback() {
+	s.eachElement(func(elem *Scope) {
+		if !elem.HasError() {
+			now := NowFunc()
+			elem.SetColumn(FieldCreatedAt, now)
+			elem.SetColumn(FieldUpdatedAt, now)
+		}
+	})
+}
 
-	//call callbacks
-	if !result.HasError() {
-		result.CallMethod(methBeforeSave)
+//[create step 5] was "createCallback"
+func (s *Scope) insertCallback() {
+	if s.HasError() {
+		return
 	}
-	if !result.HasError() {
-		result.CallMethod(methBeforeCreate)
+	if s.elements != nil {
+		s.insertElements()
+		return
 	}
+	var (
+		//because we're using it in a for, we're getting it once
+		dialect                            = s.con.parent.dialect
+		returningColumn                    = strEverything
+		quotedTableName                    = s.quotedTableName()
+		primaryField                       = s.PK()
+		extraOption, columns, placeholders string
+		values                             []interface{}
+	)
 
-	//save associations
-	if result.shouldSaveAssociations() {
-		result = result.saveBeforeAssociationsCallback()
+	//avoid call if we don't need to
+	if s.con.logLevel() >= LevelInfo {
+		defer s.trace(NowFunc())
 	}
 
-	//set time fields accordingly
-	if !result.HasError() {
-		now := NowFunc()
-		result.SetColumn(FieldCreatedAt, now)
-		result.SetColumn(FieldUpdatedAt, now)
+	s.initVersion()
+	if s.HasError() {
+		return
+	}
+	columns, values, s.blankColumns = s.insertColumns()
+	for _, value := range values {
+		if placeholders != "" {
+			placeholders += ","
+		}
+		placeholders += s.Search.addToVars(value, dialect)
 	}
 
-	var blankColumnsWithDefaultValue string
-
-	//Was "createCallback" method
-	if !result.HasError() {
-		var (
-			//because we're using it in a for, we're getting it once
-			dialect                            = result.con.parent.dialect
-			returningColumn                    = strEverything
-			quotedTableName                    = result.quotedTableName()
-			primaryField                       = result.PK()
-			extraOption, columns, placeholders string
-		)
+	if str, ok := s.Get(gormSettingInsertOpt); ok {
+		extraOption = fmt.Sprint(str)
+	}
 
-		//avoid call if we don't need to
-		if result.con.logMode == LogVerbose || s.con.logMode == LogDebug {
-			defer result.trace(NowFunc())
-		}
+	if primaryField != nil {
+		returningColumn = s.con.quote(primaryField.DBName)
+	}
 
-		for _, field := range result.Fields() {
-			if !result.Search.changeableField(field) {
-				continue
-			}
+	lastInsertIDReturningSuffix := dialect.LastInsertIDReturningSuffix(quotedTableName, returningColumn)
+	onConflict, conflict := s.onConflictSQL()
+	if s.HasError() {
+		return
+	}
+	if conflict.readBack() {
+		lastInsertIDReturningSuffix = ""
+	}
 
-			if field.IsNormal() {
-				isBlankWithDefaultValue := field.IsBlank() && field.HasDefaultValue()
-				isNotPKOrBlank := !field.IsPrimaryKey() || !field.IsBlank()
-				if isBlankWithDefaultValue {
-					if blankColumnsWithDefaultValue != "" {
-						blankColumnsWithDefaultValue += ","
-					}
-					blankColumnsWithDefaultValue += s.con.quote(field.DBName)
-				} else if isNotPKOrBlank {
-					if columns != "" {
-						columns += ","
-					}
-					columns += s.con.quote(field.DBName)
-					if placeholders != "" {
-						placeholders += ","
-					}
-					placeholders += result.Search.addToVars(field.Value.Interface(), dialect)
-				}
-			} else {
-				if field.HasRelations() && field.RelationIsBelongsTo() {
-					ForeignDBNames := field.GetForeignDBNames()
-					for _, foreignKey := range ForeignDBNames {
-						foreignField, ok := result.FieldByName(foreignKey)
-						if ok && !result.Search.changeableField(foreignField) {
-							if columns != "" {
-								columns += ","
-							}
-							columns += s.con.quote(foreignField.DBName)
-							if placeholders != "" {
-								placeholders += ","
-							}
-							placeholders += result.Search.addToVars(foreignField.Value.Interface(), dialect)
-						}
-					}
+	if columns == "" {
+		s.Raw(fmt.Sprintf(
+			"INSERT INTO %v DEFAULT VALUES%v%v%v",
+			quotedTableName,
+			addExtraSpaceIfExist(onConflict),
+			addExtraSpaceIfExist(extraOption),
+			addExtraSpaceIfExist(lastInsertIDReturningSuffix),
+		))
+	} else {
+		s.Raw(fmt.Sprintf(
+			"INSERT INTO %v (%v) VALUES (%v)%v%v%v",
+			s.quotedTableName(),
+			columns,
+			placeholders,
+			addExtraSpaceIfExist(onConflict),
+			addExtraSpaceIfExist(extraOption),
+			addExtraSpaceIfExist(lastInsertIDReturningSuffix),
+		))
+	}
+
+	// execute create sql
+	if lastInsertIDReturningSuffix == "" || primaryField == nil {
+		if execResult, err := s.Search.Exec(s); s.Err(err) == nil {
+			// set rows affected count
+			//s.con.RowsAffected, _ = execResult.RowsAffected()
+
+			// set primary value to primary field
+			if conflict.readBack() {
+				s.reloadUpserted(conflict)
+			} else if conflict.insertedAll(dialect, s.con.RowsAffected, 1) && primaryField != nil && primaryField.IsBlank() {
+				if primaryValue, err := execResult.LastInsertId(); s.Err(err) == nil {
+					s.Err(primaryField.Set(primaryValue))
+					s.con.lastInsertID = primaryValue
 				}
 			}
 		}
-
-		if str, ok := result.Get(gormSettingInsertOpt); ok {
-			extraOption = fmt.Sprint(str)
-		}
-
-		if primaryField != nil {
-			returningColumn = s.con.quote(primaryField.DBName)
+	} else {
+		row, info := s.Search.queryRow(s)
+		err := row.Scan(primaryField.Value.Addr().Interface())
+		if err == sql.ErrNoRows && s.con.dryRun != nil {
+			//a dry run returns no rows : the insert is reported, without a primary key
+			s.con.RowsAffected, err = 1, nil
+		} else if err == sql.ErrNoRows && conflict != nil {
+			//upsert which did nothing
+			s.con.RowsAffected, err = 0, nil
+		} else if err = s.statementError(err); s.Err(err) == nil {
+			primaryField.UnsetIsBlank()
+			s.con.RowsAffected = 1
+			s.con.lastInsertID = intValue(primaryField.Value)
 		}
-
-		lastInsertIDReturningSuffix := dialect.LastInsertIDReturningSuffix(quotedTableName, returningColumn)
-
-		if columns == "" {
-			result.Raw(fmt.Sprintf(
-				"INSERT INTO %v DEFAULT VALUES%v%v",
-				quotedTableName,
-				addExtraSpaceIfExist(extraOption),
-				addExtraSpaceIfExist(lastInsertIDReturningSuffix),
-			))
-		} else {
-			result.Raw(fmt.Sprintf(
-				"INSERT INTO %v (%v) VALUES (%v)%v%v",
-				result.quotedTableName(),
-				columns,
-				placeholders,
-				addExtraSpaceIfExist(extraOption),
-				addExtraSpaceIfExist(lastInsertIDReturningSuffix),
-			))
-		}
-
-		// execute create sql
-		if lastInsertIDReturningSuffix == "" || primaryField == nil {
-			if execResult, err := result.Search.Exec(result); result.Err(err) == nil {
-				// set rows affected count
-				//result.con.RowsAffected, _ = execResult.RowsAffected()
-
-				// set primary value to primary field
-				if primaryField != nil && primaryField.IsBlank() {
-					if primaryValue, err := execResult.LastInsertId(); result.Err(err) == nil {
-						result.Err(primaryField.Set(primaryValue))
-					}
-				}
-			}
-		} else {
-			if err := result.Search.QueryRow(result).
-				Scan(primaryField.Value.Addr().Interface()); result.Err(err) == nil {
-				primaryField.UnsetIsBlank()
-				result.con.RowsAffected = 1
-			}
+		var count int64
+		if err == nil {
+			count = s.con.RowsAffected
 		}
+		s.finishStatement(info, count, err)
 	}
-	//END - Was "createCallback" method
+}
 
-	//Was "forceReloadAfterCreateCallback" method
-	if blankColumnsWithDefaultValue != "" {
-		db := s.con.empty().Table(result.TableName()).Select(blankColumnsWithDefaultValue)
-		for _, field := range result.Fields() {
-			if field.IsPrimaryKey() && !field.IsBlank() {
-				db = db.Where(fmt.Sprintf("%v = ?", field.DBName), field.Value.Interface())
-			}
+//[create step 6] was "forceReloadAfterCreateCallback"
+func (s *Scope) forceReloadAfterCreateCallback() {
+	s.eachElement(func(elem *Scope) {
+		if elem.blankColumns != "" {
+			elem.reloadColumns(elem.blankColumns)
 		}
+	})
+}
 
-		db.Scan(result.Value)
-	}
-	//END - Was "forceReloadAfterCreateCallback" method
+//[create step 8]
+func (s *Scope) afterCreateCallback() {
+	s.eachElement(func(elem *Scope) {
+		if !elem.HasError() {
+			elem.CallMethod(methAfterCreate)
+		}
+		if !elem.HasError() {
+			elem.CallMethod(methAfterSave)
+		}
+	})
+}
 
-	//save associations
-	if result.shouldSaveAssociations() {
-		result = result.saveAfterAssociationsCallback()
+//[restore step 2]
+func (s *Scope) beforeRestoreCallback() {
+	if !s.HasError() {
+		s.CallMethod(methBeforeRestore)
 	}
+}
 
-	//call callbacks again
-	if !result.HasError() {
-		result.CallMethod(methAfterCreate)
+//[restore step 3]
+func (s *Scope) restoreRowsCallback() {
+	softDelete := s.GetModelStruct().softDelete
+	if s.HasError() || softDelete == nil {
+		return
 	}
-	if !result.HasError() {
-		result.CallMethod(methAfterSave)
+	var extraOption string
+	if str, ok := s.Get(gormSettingUpdateOpt); ok {
+		extraOption = fmt.Sprint(str)
 	}
 
-	//attempt to commit in the end
-	return result.commitOrRollback(txStarted)
+	s.Search.setOnlyTrashed()
+	s.Raw(fmt.Sprintf(
+		"UPDATE %v SET %v=%v%v%v",
+		s.quotedTableName(),
+		s.con.quote(softDelete.column),
+		s.Search.addToVars(softDelete.aliveValue(), s.con.parent.dialect),
+		addExtraSpaceIfExist(s.Search.combinedConditionSql(s)),
+		addExtraSpaceIfExist(extraOption),
+	)).Exec()
+
+	if field, ok := s.FieldByName(softDelete.column); ok && !s.HasError() && s.rValue.Kind() == reflect.Struct {
+		s.Err(field.Set(softDelete.aliveValue()))
+	}
 }
 
-//calls methods after update
-func (s *Scope) postUpdate(attrs interface{}) *Scope {
-	if attrs != nil {
-		updateMaps, hasUpdate := updatedAttrsWithValues(s, attrs)
-		if hasUpdate {
-			s.updateMaps = updateMaps
-		} else {
-			//we stop chain calls
-			return s
-		}
+//[restore step 4]
+func (s *Scope) afterRestoreCallback() {
+	if !s.HasError() {
+		s.CallMethod(methAfterRestore)
 	}
+}
 
-	//begin transaction
-	result, txStarted := s.begin()
+//[update step 1]
+func (s *Scope) assignUpdatingAttributesCallback() {
+	if s.updateAttrs == nil {
+		return
+	}
+	if updateMaps, hasUpdate := updatedAttrsWithValues(s, s.updateAttrs); hasUpdate {
+		s.updateMaps = updateMaps
+	} else {
+		//nothing to update, we stop chain calls
+		s.skipLeft = true
+	}
+}
 
-	if _, ok := result.Get(gormSettingUpdateColumn); !ok {
-		if !result.HasError() {
-			result.CallMethod(methBeforeSave)
+//[update step 3]
+func (s *Scope) beforeUpdateCallback() {
+	if _, ok := s.Get(gormSettingUpdateColumn); !ok {
+		if !s.HasError() {
+			s.CallMethod(methBeforeSave)
 		}
-		if !result.HasError() {
-			result.CallMethod(methBeforeUpdate)
+		if !s.HasError() {
+			s.CallMethod(methBeforeUpdate)
 		}
 	}
+}
 
-	//save associations
-	if result.shouldSaveAssociations() {
-		result = result.saveBeforeAssociationsCallback()
+//[update step 5]
+func (s *Scope) updateTimeStampForUpdateCallback() {
+	if _, ok := s.Get(gormSettingUpdateColumn); !ok {
+		s.SetColumn(FieldUpdatedAt, NowFunc())
 	}
+}
 
-	//update the updated at column
-	if _, ok := result.Get(gormSettingUpdateColumn); !ok {
-		result.SetColumn(FieldUpdatedAt, NowFunc())
+//[update step 6] was "updateCallback"
+func (s *Scope) updateRowsCallback() {
+	if s.HasError() {
+		return
 	}
+	var (
+		//because we're using it in a for, we're getting it once
+		dialect          = s.con.parent.dialect
+		extraOption, sql string
+		version, current = s.lockVersion()
+	)
 
-	//Was "updateCallback"
-	if !result.HasError() {
-		var (
-			//because we're using it in a for, we're getting it once
-			dialect          = result.con.parent.dialect
-			extraOption, sql string
-		)
+	if s.updateMaps != nil {
+		for column, value := range s.updateMaps {
+			if sql != "" {
+				sql += ", "
+			}
+			if field, ok := s.FieldByName(column); ok && field.IsSensitive() {
+				value = Sensitive(value)
+			}
+			sql += fmt.Sprintf(
+				"%v = %v",
+				s.con.quote(column),
+				s.Search.addToVars(value, dialect),
+			)
 
-		if result.updateMaps != nil {
-			for column, value := range result.updateMaps {
+		}
+	} else {
+		for _, field := range s.Fields() {
+			if !s.Search.changeableField(field) && (version == nil || field != version) {
+				continue
+			}
+			if !field.IsPrimaryKey() && field.IsNormal() {
 				if sql != "" {
 					sql += ", "
 				}
 				sql += fmt.Sprintf(
 					"%v = %v",
-					s.con.quote(column),
-					result.Search.addToVars(value, dialect),
+					s.con.quote(field.DBName),
+					s.Search.addToVars(field.varValue(), dialect),
 				)
-
-			}
-		} else {
-			for _, field := range result.Fields() {
-				if !result.Search.changeableField(field) {
-					continue
-				}
-				if !field.IsPrimaryKey() && field.IsNormal() {
-					if sql != "" {
-						sql += ", "
-					}
-					sql += fmt.Sprintf(
-						"%v = %v",
-						s.con.quote(field.DBName),
-						result.Search.addToVars(field.Value.Interface(), dialect),
-					)
-				} else {
-					if field.HasRelations() && field.RelationIsBelongsTo() {
-						ForeignDBNames := field.GetForeignDBNames()
-						for _, foreignKey := range ForeignDBNames {
-							foreignField, ok := result.FieldByName(foreignKey)
-							if ok && !result.Search.changeableField(foreignField) {
-								if sql != "" {
-									sql += ", "
-								}
-								sql += fmt.Sprintf(
-									"%v = %v",
-									s.con.quote(foreignField.DBName),
-									result.Search.addToVars(
-										foreignField.Value.Interface(),
-										dialect,
-									),
-								)
+			} else {
+				if field.HasRelations() && field.RelationIsBelongsTo() {
+					ForeignDBNames := field.GetForeignDBNames()
+					for _, foreignKey := range ForeignDBNames {
+						foreignField, ok := s.FieldByName(foreignKey)
+						if ok && !s.Search.changeableField(foreignField) {
+							if sql != "" {
+								sql += ", "
 							}
+							sql += fmt.Sprintf(
+								"%v = %v",
+								s.con.quote(foreignField.DBName),
+								s.Search.addToVars(
+									foreignField.varValue(),
+									dialect,
+								),
+							)
 						}
 					}
 				}
-
 			}
-		}
 
-		if str, ok := result.Get(gormSettingUpdateOpt); ok {
-			extraOption = fmt.Sprint(str)
 		}
+	}
 
-		if sql != "" && s.TableName() != "" {
-			result.Raw(fmt.Sprintf(
-				"UPDATE %v SET %v%v%v",
-				result.quotedTableName(),
-				sql,
-				addExtraSpaceIfExist(result.Search.combinedConditionSql(result)),
-				addExtraSpaceIfExist(extraOption),
-			)).Exec()
-		}
+	if str, ok := s.Get(gormSettingUpdateOpt); ok {
+		extraOption = fmt.Sprint(str)
 	}
-	//END Was "updateCallback"
 
-	//save associations
-	if result.shouldSaveAssociations() {
-		result = result.saveAfterAssociationsCallback()
+	if sql != "" && s.TableName() != "" {
+		s.Raw(fmt.Sprintf(
+			"UPDATE %v SET %v%v%v",
+			s.quotedTableName(),
+			sql,
+			addExtraSpaceIfExist(s.Search.combinedConditionSql(s)),
+			addExtraSpaceIfExist(extraOption),
+		)).Exec()
+		if version != nil && !s.HasError() && s.con.RowsAffected == 0 {
+			s.Err(ErrStaleObject)
+		}
+	}
+	//the value keeps the version it was read with
+	if version != nil && s.HasError() {
+		version.Set(current)
 	}
+}
 
-	if _, ok := result.Get(gormSettingUpdateColumn); !ok {
-		if !result.HasError() {
-			result.CallMethod(methAfterUpdate)
+//[update step 8]
+func (s *Scope) afterUpdateCallback() {
+	if _, ok := s.Get(gormSettingUpdateColumn); !ok {
+		if !s.HasError() {
+			s.CallMethod(methAfterUpdate)
 		}
-		if !result.HasError() {
-			result.CallMethod(methAfterSave)
+		if !s.HasError() {
+			s.CallMethod(methAfterSave)
 		}
 	}
-
-	return result.commitOrRollback(txStarted)
 }
 
-//calls methods after deletion
-func (s *Scope) postDelete() *Scope {
-	//begin transaction
-	result, txStarted := s.begin()
-
-	//call callbacks
-	if !result.HasError() {
-		result.CallMethod(methBeforeDelete)
+//[delete step 2]
+func (s *Scope) beforeDeleteCallback() {
+	if !s.HasError() {
+		s.CallMethod(methBeforeDelete)
 	}
+}
 
-	//Was "deleteCallback"
-	if !result.HasError() {
-		var extraOption string
-		if str, ok := result.Get(gormSettingDeleteOpt); ok {
-			extraOption = fmt.Sprint(str)
-		}
+//[delete step 4] was "deleteCallback"
+func (s *Scope) deleteRowsCallback() {
+	if s.HasError() {
+		return
+	}
+	var extraOption string
+	if str, ok := s.Get(gormSettingDeleteOpt); ok {
+		extraOption = fmt.Sprint(str)
+	}
 
-		if !result.Search.isUnscoped() && result.GetModelStruct().HasColumn(FieldDeletedAt) {
-			result.Raw(fmt.Sprintf(
-				"UPDATE %v SET deleted_at=%v%v%v",
-				result.quotedTableName(),
-				result.Search.addToVars(NowFunc(), result.con.parent.dialect),
-				addExtraSpaceIfExist(result.Search.combinedConditionSql(result)),
-				addExtraSpaceIfExist(extraOption),
-			)).Exec()
-		} else {
-			result.Raw(fmt.Sprintf(
-				"DELETE FROM %v%v%v",
-				result.quotedTableName(),
-				addExtraSpaceIfExist(result.Search.combinedConditionSql(result)),
-				addExtraSpaceIfExist(extraOption),
-			)).Exec()
-		}
+	if softDelete := s.GetModelStruct().softDelete; !s.Search.isUnscoped() && softDelete != nil {
+		s.Raw(fmt.Sprintf(
+			"UPDATE %v SET %v=%v%v%v",
+			s.quotedTableName(),
+			s.con.quote(softDelete.column),
+			s.Search.addToVars(softDelete.deletedValue(), s.con.parent.dialect),
+			addExtraSpaceIfExist(s.Search.combinedConditionSql(s)),
+			addExtraSpaceIfExist(extraOption),
+		)).Exec()
+	} else {
+		s.Raw(fmt.Sprintf(
+			"DELETE FROM %v%v%v",
+			s.quotedTableName(),
+			addExtraSpaceIfExist(s.Search.combinedConditionSql(s)),
+			addExtraSpaceIfExist(extraOption),
+		)).Exec()
 	}
-	//END - Was "deleteCallback"
+}
 
-	//call callbacks
-	if !result.HasError() {
-		result.CallMethod(methAfterDelete)
+//[delete step 5]
+func (s *Scope) afterDeleteCallback() {
+	if !s.HasError() {
+		s.CallMethod(methAfterDelete)
 	}
+}
 
-	//attempt to commit
-	return result.commitOrRollback(txStarted)
+//[create step 1] [delete step 1] [update step 2]
+func (s *Scope) beginTransactionCallback() {
+	_, s.txStarted = s.begin()
 }
 
-////////////////////////////////////////////////////////////////////////////////
-// internal callbacks functions
-////////////////////////////////////////////////////////////////////////////////
-//[create step 1] [delete step 1] [update step 1]
 // Begin start a transaction
+// Note : if the connection already holds a `*sql.Tx` (via `DBCon.Begin` or `DBCon.Transaction`) no transaction
+// is started here, so the outer one decides the commit or rollback. See also the "gorm:skip_transaction" setting
 func (s *Scope) begin() (*Scope, bool) {
+	if !s.needsTransaction() {
+		return s, false
+	}
 	if db, ok := s.con.sqli.(sqlDb); ok {
-		//parent db implements Begin() -> call Begin()
-		if tx, err := db.Begin(); err == nil {
+		//parent db implements BeginTx() -> call BeginTx()
+		if tx, err := db.BeginTx(s.Context(), s.con.txOptions); err == nil {
 			//TODO : @Badu - maybe the parent should do so, since it's owner of db.db
 			//parent db.db implements Exec(), Prepare(), Query() and QueryRow()
 			//TODO : @Badu - it's paired with commit or rollback - see below
 			s.con.sqli = interface{}(tx).(sqlInterf)
+			s.con.txHooks = &txHooks{}
 			return s, true
 		}
 	}
 	return s, false
 }
 
-//[create step 3] [update step 3]
-func (s *Scope) saveBeforeAssociationsCallback() *Scope {
+//[create step 3] [update step 4]
+func (s *Scope) saveBeforeAssociationsCallback() {
+	s.eachElement(func(elem *Scope) {
+		if elem.shouldSaveAssociations() {
+			elem.saveBeforeAssociations()
+		}
+	})
+}
+
+func (s *Scope) saveBeforeAssociations() {
 	for _, field := range s.Fields() {
 		if field.IsBlank() || field.IsIgnored() || !s.Search.changeableField(field) {
 			continue
@@ -1076,7 +1957,9 @@ func (s *Scope) saveBeforeAssociationsCallback() *Scope {
 
 		if s.willSaveFieldAssociations(field) && field.RelationIsBelongsTo() {
 			fieldValue := field.Value.Addr().Interface()
+			endSpan := s.startSpan(SpanAssociation, SpanAttribute{Key: "field", Value: field.StructName})
 			s.Err(s.con.empty().Save(fieldValue).Error)
+			endSpan()
 			var (
 				ForeignFieldNames         = field.GetForeignFieldNames()
 				AssociationForeignDBNames = field.GetAssociationDBNames()
@@ -1092,11 +1975,18 @@ func (s *Scope) saveBeforeAssociationsCallback() *Scope {
 			}
 		}
 	}
-	return s
 }
 
-//[create step 7] [update step 6]
-func (s *Scope) saveAfterAssociationsCallback() *Scope {
+//[create step 7] [update step 7]
+func (s *Scope) saveAfterAssociationsCallback() {
+	s.eachElement(func(elem *Scope) {
+		if elem.shouldSaveAssociations() {
+			elem.saveAfterAssociations()
+		}
+	})
+}
+
+func (s *Scope) saveAfterAssociations() {
 	for _, field := range s.Fields() {
 		if field.IsBlank() || field.IsIgnored() || !s.Search.changeableField(field) {
 			continue
@@ -1104,6 +1994,7 @@ func (s *Scope) saveAfterAssociationsCallback() *Scope {
 
 		//Attention : relationship.Kind <= HAS_ONE means except BELONGS_TO
 		if s.willSaveFieldAssociations(field) && field.RelKind() <= relHasOne {
+			endSpan := s.startSpan(SpanAssociation, SpanAttribute{Key: "field", Value: field.StructName})
 			value := field.Value
 			ForeignFieldNames := field.GetForeignFieldNames()
 			AssociationForeignDBNames := field.GetAssociationDBNames()
@@ -1158,13 +2049,26 @@ func (s *Scope) saveAfterAssociationsCallback() *Scope {
 				}
 				s.Err(s.con.empty().Save(elem).Error)
 			}
+			endSpan()
 		}
 
 	}
-	return s
 }
 
-//[create step 9] [delete step 5] [update step 8]
+//[create step 9] [delete step 6] [update step 9]
+func (s *Scope) commitOrRollbackCallback() {
+	if _, ok := s.Get(gormSettingUpdateColumn); !ok {
+		if s.elements != nil {
+			for _, elem := range s.elements {
+				elem.queueTransactionMethods(s.txStarted)
+			}
+		} else {
+			s.queueTransactionMethods(s.txStarted)
+		}
+	}
+	s.commitOrRollback(s.txStarted)
+}
+
 // CommitOrRollback commit current transaction if no error happened, otherwise will rollback it
 func (s *Scope) commitOrRollback(txStarted bool) *Scope {
 	if txStarted {
@@ -1178,7 +2082,32 @@ func (s *Scope) commitOrRollback(txStarted bool) *Scope {
 			}
 			//TODO : @Badu - it's paired with begin - see above
 			s.con.sqli = s.con.parent.sqli
+			hooks := s.con.txHooks
+			s.con.txHooks = nil
+			hooks.done(!s.HasError())
 		}
 	}
 	return s
 }
+
+// queues AfterCommit and AfterRollback methods of the value, to be called when the outcome of the transaction is known.
+// Without a transaction, the statement is already final, so they are called right away. So is AfterRollback of a
+// failed statement which wrote nothing inside a transaction of the caller (`txStarted` false). When it wrote its rows
+// (e.g. an After* hook failed), they are committed or rolled back with the transaction of the caller
+func (s *Scope) queueTransactionMethods(txStarted bool) {
+	if s.Value == nil {
+		return
+	}
+	if s.con.txHooks == nil || (s.HasError() && !txStarted && s.con.RowsAffected == 0) {
+		if s.HasError() {
+			s.CallMethod(methAfterRollback)
+		} else {
+			s.CallMethod(methAfterCommit)
+		}
+		return
+	}
+	if !s.HasError() || !txStarted {
+		s.con.txHooks.onCommit(func() { s.CallMethod(methAfterCommit) })
+	}
+	s.con.txHooks.onRollback(func() { s.CallMethod(methAfterRollback) })
+}
@@ -15,6 +15,16 @@ func SqlExpr(expression interface{}, args ...interface{}) *SqlPair {
 	return &SqlPair{expression: expression, args: args}
 }
 
+// Sensitive marks a value as sensitive, so the logs and the StatementObserver don't see it, see RedactionPolicy
+//     db.Where("password = ?", gorm.Sensitive(password)).First(&user)
+//     db.Model(&user).UpdateColumn("token", gorm.Sensitive(token))
+func Sensitive(value interface{}) interface{} {
+	if _, ok := value.(sensitiveVar); ok {
+		return value
+	}
+	return sensitiveVar{value: value}
+}
+
 func (p *SqlPair) addExpressions(values ...interface{}) {
 	p.args = append(p.args, values...)
 }
@@ -251,23 +261,57 @@ func (s *Search) exec(scope *Scope, sql string, values ...interface{}) string {
 }
 
 func (s *Search) Exec(scope *Scope) (sql.Result, error) {
-	result, err := scope.con.sqli.Exec(s.SQL, s.SQLVars...)
+	scope.con.dryRun.record(s)
+	info := scope.startStatement()
+	result, err := scope.con.sqli.ExecContext(scope.Context(), s.SQL, s.SQLVars...)
+	err = scope.statementError(err)
+	var count int64
 	if scope.Err(err) == nil {
-		count, err := result.RowsAffected()
-		if scope.Err(err) == nil {
+		count, err = result.RowsAffected()
+		if scope.Err(scope.statementError(err)) == nil {
 			scope.con.RowsAffected = count
 		}
 	}
+	scope.finishStatement(info, count, err)
 	return result, err
 }
 
+// Query executes the query of the search. The rows are handed to the caller, so only the time until the first row
+// is measured and the number of rows is not known (-1)
 func (s *Search) Query(scope *Scope) (*sql.Rows, error) {
-	rows, err := scope.con.sqli.Query(s.SQL, s.SQLVars...)
+	rows, info, err := s.query(scope)
+	if err == nil {
+		scope.finishStatement(info, -1, nil)
+	}
 	return rows, err
 }
 
+// QueryRow executes the single row query of the search. The error of a single row comes with Scan, which is done
+// by the caller, so the statement is reported as successful
 func (s *Search) QueryRow(scope *Scope) *sql.Row {
-	return scope.con.sqli.QueryRow(s.SQL, s.SQLVars...)
+	row, info := s.queryRow(scope)
+	scope.finishStatement(info, -1, nil)
+	return row
+}
+
+//when no error happens, the statement is left open : the caller finishes it once the rows were read
+func (s *Search) query(scope *Scope) (*sql.Rows, *StatementInfo, error) {
+	scope.con.dryRun.record(s)
+	info := scope.startStatement()
+	rows, err := scope.con.sqli.QueryContext(scope.Context(), s.SQL, s.SQLVars...)
+	if err != nil {
+		err = scope.statementError(err)
+		scope.finishStatement(info, -1, err)
+		return nil, nil, err
+	}
+	return rows, info, nil
+}
+
+//the statement is left open : the caller finishes it with the error of Scan
+func (s *Search) queryRow(scope *Scope) (*sql.Row, *StatementInfo) {
+	scope.con.dryRun.record(s)
+	info := scope.startStatement()
+	return scope.con.sqli.QueryRowContext(scope.Context(), s.SQL, s.SQLVars...), info
 }
 
 //should remain unused
@@ -343,6 +387,15 @@ func (s *Search) setUnscoped() *Search {
 	return s
 }
 
+func (s *Search) isOnlyTrashed() bool {
+	return s.flags&(1<<srchIsOnlyTrashed) != 0
+}
+
+func (s *Search) setOnlyTrashed() *Search {
+	s.flags = s.flags | (1 << srchIsOnlyTrashed)
+	return s
+}
+
 func (s *Search) checkFieldIncluded(field *StructField) bool {
 	fromPair := s.getFirst(condSelectQuery)
 	if fromPair != nil {
@@ -385,6 +438,10 @@ func (s *Search) addToVars(value interface{}, dialect Dialect) string {
 		}
 		return exp
 	}
+	if sensitive, ok := value.(sensitiveVar); ok {
+		s.sensitiveVars = append(s.sensitiveVars, len(s.SQLVars))
+		value = sensitive.value
+	}
 	s.SQLVars = append(s.SQLVars, value)
 	return dialect.BindVar(len(s.SQLVars))
 }
@@ -396,12 +453,12 @@ func (s *Search) whereSQL(scope *Scope) string {
 		quotedTableName                = scope.quotedTableName()
 	)
 
-	if !s.isUnscoped() && scope.GetModelStruct().HasColumn(fieldDeletedAtName) {
-
-		if primarySQL != "" {
-			primarySQL += " AND "
+	if softDelete := scope.GetModelStruct().softDelete; softDelete != nil {
+		if s.isOnlyTrashed() {
+			primarySQL += softDelete.deletedSQL(scope, s)
+		} else if !s.isUnscoped() {
+			primarySQL += softDelete.aliveSQL(scope, s)
 		}
-		primarySQL += fmt.Sprintf("%v.%s IS NULL", quotedTableName, fieldDeletedAtName)
 	}
 
 	if !scope.PrimaryKeyZero() {
@@ -413,7 +470,7 @@ func (s *Search) whereSQL(scope *Scope) string {
 				"%v.%v = %v",
 				quotedTableName,
 				scope.con.quote(field.DBName),
-				s.addToVars(field.Value.Interface(), dialect),
+				s.addToVars(field.varValue(), dialect),
 			)
 
 		}
@@ -471,6 +528,7 @@ func (s *Search) buildWhereCondition(fromPair SqlPair, scope *Scope) string {
 		quotedTableName = scope.quotedTableName()
 		dialect         = scope.con.parent.dialect
 		quotedPKName    = scope.con.quote(scope.PKName())
+		sensitivePK     = scope.isSensitive(scope.PKName())
 	)
 
 	switch expType := fromPair.expression.(type) {
@@ -481,7 +539,7 @@ func (s *Search) buildWhereCondition(fromPair SqlPair, scope *Scope) string {
 				"(%v.%v = %v)",
 				quotedTableName,
 				quotedPKName,
-				s.addToVars(expType, dialect),
+				s.addToVars(markSensitive(expType, sensitivePK), dialect),
 			)
 		} else if expType != "" {
 			str = fmt.Sprintf("(%v)", expType)
@@ -497,7 +555,7 @@ func (s *Search) buildWhereCondition(fromPair SqlPair, scope *Scope) string {
 		uint32,
 		uint64,
 		sql.NullInt64:
-		return fmt.Sprintf("(%v.%v = %v)", quotedTableName, quotedPKName, s.addToVars(expType, dialect))
+		return fmt.Sprintf("(%v.%v = %v)", quotedTableName, quotedPKName, s.addToVars(markSensitive(expType, sensitivePK), dialect))
 	case []int,
 		[]int8,
 		[]int16,
@@ -512,7 +570,7 @@ func (s *Search) buildWhereCondition(fromPair SqlPair, scope *Scope) string {
 		[]interface{}:
 		str = fmt.Sprintf("(%v.%v IN (?))", quotedTableName, quotedPKName)
 		//TODO : @Badu - seems really bad "work around" (boiler plate logic)
-		fromPair.args = []interface{}{expType}
+		fromPair.args = []interface{}{markSensitive(expType, sensitivePK)}
 	case map[string]interface{}:
 		var sqls []string
 		for key, value := range expType {
@@ -522,7 +580,7 @@ func (s *Search) buildWhereCondition(fromPair SqlPair, scope *Scope) string {
 						"(%v.%v = %v)",
 						quotedTableName,
 						scope.con.quote(key),
-						s.addToVars(value, dialect),
+						s.addToVars(markSensitive(value, scope.isSensitive(key)), dialect),
 					),
 				)
 			} else {
@@ -546,7 +604,7 @@ func (s *Search) buildWhereCondition(fromPair SqlPair, scope *Scope) string {
 						"(%v.%v = %v)",
 						newScope.quotedTableName(),
 						scope.con.quote(field.DBName),
-						s.addToVars(field.Value.Interface(), dialect),
+						s.addToVars(field.varValue(), dialect),
 					),
 				)
 			}
@@ -555,14 +613,19 @@ func (s *Search) buildWhereCondition(fromPair SqlPair, scope *Scope) string {
 	}
 
 	for _, arg := range fromPair.args {
+		//a sensitive slice is expanded as well, each of its values being sensitive
+		_, sensitive := arg.(sensitiveVar)
+		if sensitive {
+			arg = arg.(sensitiveVar).value
+		}
 		switch reflect.ValueOf(arg).Kind() {
 		case reflect.Slice: // For where("id in (?)", []int64{1,2})
 			if bytes, ok := arg.([]byte); ok {
-				str = strings.Replace(str, "?", s.addToVars(bytes, dialect), 1)
+				str = strings.Replace(str, "?", s.addToVars(markSensitive(bytes, sensitive), dialect), 1)
 			} else if values := reflect.ValueOf(arg); values.Len() > 0 {
 				var tempMarks []string
 				for i := 0; i < values.Len(); i++ {
-					tempMarks = append(tempMarks, s.addToVars(values.Index(i).Interface(), dialect))
+					tempMarks = append(tempMarks, s.addToVars(markSensitive(values.Index(i).Interface(), sensitive), dialect))
 				}
 				str = strings.Replace(str, "?", strings.Join(tempMarks, ","), 1)
 			} else {
@@ -573,12 +636,19 @@ func (s *Search) buildWhereCondition(fromPair SqlPair, scope *Scope) string {
 				arg, _ = valuer.Value()
 			}
 
-			str = strings.Replace(str, "?", s.addToVars(arg, dialect), 1)
+			str = strings.Replace(str, "?", s.addToVars(markSensitive(arg, sensitive), dialect), 1)
 		}
 	}
 	return str
 }
 
+func markSensitive(value interface{}, sensitive bool) interface{} {
+	if sensitive {
+		return Sensitive(value)
+	}
+	return value
+}
+
 func (s *Search) buildNotCondition(fromPair SqlPair, scope *Scope) string {
 	var (
 		str             string
@@ -594,6 +664,9 @@ func (s *Search) buildNotCondition(fromPair SqlPair, scope *Scope) string {
 		// is number
 		if regExpNumberMatcher.MatchString(exprType) {
 			id, _ := strconv.Atoi(exprType)
+			if scope.isSensitive(primaryKey) {
+				return fmt.Sprintf("(%v <> %v)", scope.con.quote(primaryKey), s.addToVars(Sensitive(id), dialect))
+			}
 			return fmt.Sprintf("(%v <> %v)", scope.con.quote(primaryKey), id)
 		} else if regExpLikeInMatcher.MatchString(exprType) {
 			str = fmt.Sprintf(" NOT (%v) ", exprType)
@@ -613,6 +686,9 @@ func (s *Search) buildNotCondition(fromPair SqlPair, scope *Scope) string {
 		uint32,
 		uint64,
 		sql.NullInt64:
+		if scope.isSensitive(primaryKey) {
+			return fmt.Sprintf("(%v.%v <> %v)", quotedTableName, scope.con.quote(primaryKey), s.addToVars(Sensitive(exprType), dialect))
+		}
 		return fmt.Sprintf("(%v.%v <> %v)", quotedTableName, scope.con.quote(primaryKey), exprType)
 	case []int,
 		[]int8,
@@ -628,7 +704,7 @@ func (s *Search) buildNotCondition(fromPair SqlPair, scope *Scope) string {
 		if reflect.ValueOf(exprType).Len() > 0 {
 			str = fmt.Sprintf("(%v.%v NOT IN (?))", quotedTableName, scope.con.quote(primaryKey))
 			//TODO : @Badu - seems really bad "work around" (boiler plate logic)
-			fromPair.args = []interface{}{exprType}
+			fromPair.args = []interface{}{markSensitive(exprType, scope.isSensitive(primaryKey))}
 		}
 		return ""
 	case map[string]interface{}:
@@ -639,7 +715,7 @@ func (s *Search) buildNotCondition(fromPair SqlPair, scope *Scope) string {
 						"(%v.%v <> %v)",
 						quotedTableName,
 						scope.con.quote(key),
-						s.addToVars(value, dialect),
+						s.addToVars(markSensitive(value, scope.isSensitive(key)), dialect),
 					),
 				)
 			} else {
@@ -662,7 +738,7 @@ func (s *Search) buildNotCondition(fromPair SqlPair, scope *Scope) string {
 						"(%v.%v <> %v)",
 						newScope.quotedTableName(),
 						scope.con.quote(field.DBName),
-						s.addToVars(field.Value.Interface(), dialect),
+						s.addToVars(field.varValue(), dialect),
 					),
 				)
 			}
@@ -671,14 +747,19 @@ func (s *Search) buildNotCondition(fromPair SqlPair, scope *Scope) string {
 	}
 
 	for _, arg := range fromPair.args {
+		//a sensitive slice is expanded as well, each of its values being sensitive
+		_, sensitive := arg.(sensitiveVar)
+		if sensitive {
+			arg = arg.(sensitiveVar).value
+		}
 		switch reflect.ValueOf(arg).Kind() {
 		case reflect.Slice: // For where("id in (?)", []int64{1,2})
 			if bytes, ok := arg.([]byte); ok {
-				str = strings.Replace(str, "?", s.addToVars(bytes, dialect), 1)
+				str = strings.Replace(str, "?", s.addToVars(markSensitive(bytes, sensitive), dialect), 1)
 			} else if values := reflect.ValueOf(arg); values.Len() > 0 {
 
 				for i := 0; i < values.Len(); i++ {
-					tempMarks = append(tempMarks, s.addToVars(values.Index(i).Interface(), dialect))
+					tempMarks = append(tempMarks, s.addToVars(markSensitive(values.Index(i).Interface(), sensitive), dialect))
 				}
 				str = strings.Replace(str, "?", strings.Join(tempMarks, ","), 1)
 			} else {
@@ -688,7 +769,7 @@ func (s *Search) buildNotCondition(fromPair SqlPair, scope *Scope) string {
 			if scanner, ok := interface{}(arg).(driver.Valuer); ok {
 				arg, _ = scanner.Value()
 			}
-			str = strings.Replace(notEqualSQL, "?", s.addToVars(arg, dialect), 1)
+			str = strings.Replace(notEqualSQL, "?", s.addToVars(markSensitive(arg, sensitive), dialect), 1)
 		}
 	}
 	return str
@@ -873,6 +954,7 @@ func (s *Search) doPreload(scope *Scope) {
 						continue
 					}
 
+					endSpan := currentScope.startSpan(SpanPreload, SpanAttribute{Key: "field", Value: preloadKey})
 					switch field.RelKind() {
 					case relHasOne, relHasMany, relBelongsTo:
 						handleRelationPreload(currentScope, field, currentPreloadConditions)
@@ -881,6 +963,7 @@ func (s *Search) doPreload(scope *Scope) {
 					default:
 						scope.Err(fmt.Errorf(errUnsupportedRelation, field.RelKind()))
 					}
+					endSpan()
 
 					preloadedMap[preloadKey] = true
 					break
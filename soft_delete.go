@@ -0,0 +1,45 @@
+package gorm
+
+import (
+	"fmt"
+)
+
+//the value of the column for the rows which are not deleted, nil stands for NULL
+func (c *softDeleteColumn) aliveValue() interface{} {
+	switch c.kind {
+	case SoftDeleteFlag:
+		return false
+	case SoftDeleteUnix:
+		return 0
+	}
+	return nil
+}
+
+//the value written by a soft delete
+func (c *softDeleteColumn) deletedValue() interface{} {
+	switch c.kind {
+	case SoftDeleteFlag:
+		return true
+	case SoftDeleteUnix:
+		return NowFunc().Unix()
+	}
+	return NowFunc()
+}
+
+//the condition which keeps the deleted rows out
+func (c *softDeleteColumn) aliveSQL(scope *Scope, search *Search) string {
+	column := fmt.Sprintf("%v.%v", scope.quotedTableName(), scope.con.quote(c.column))
+	if value := c.aliveValue(); value != nil {
+		return column + " = " + search.addToVars(value, scope.con.parent.dialect)
+	}
+	return column + " IS NULL"
+}
+
+//the condition which keeps only the deleted rows
+func (c *softDeleteColumn) deletedSQL(scope *Scope, search *Search) string {
+	column := fmt.Sprintf("%v.%v", scope.quotedTableName(), scope.con.quote(c.column))
+	if value := c.aliveValue(); value != nil {
+		return column + " <> " + search.addToVars(value, scope.con.parent.dialect)
+	}
+	return column + " IS NOT NULL"
+}
@@ -0,0 +1,103 @@
+package gorm
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// NewStatementMetrics returns an in memory StatementObserver, with latency buckets in ascending order
+// (defaults from 1ms to 5s)
+//     metrics := gorm.NewStatementMetrics(10*time.Millisecond, 100*time.Millisecond)
+//     db.SetStatementObserver(metrics)
+func NewStatementMetrics(buckets ...time.Duration) *StatementMetrics {
+	if len(buckets) == 0 {
+		buckets = defaultLatencyBuckets
+	}
+	return &StatementMetrics{
+		l:       new(sync.Mutex),
+		buckets: buckets,
+		series:  make(map[statementKey]*StatementSeries),
+	}
+}
+
+// StatementStarted nothing to do, the statements are counted when they finish
+func (m *StatementMetrics) StatementStarted(info *StatementInfo) {}
+
+// StatementFinished aggregates the statement into its table and operation series
+func (m *StatementMetrics) StatementFinished(info *StatementInfo) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	key := statementKey{table: info.Table, operation: info.Operation}
+	series, ok := m.series[key]
+	if !ok {
+		series = &StatementSeries{
+			Table:     info.Table,
+			Operation: info.Operation,
+			Buckets:   m.buckets,
+			Histogram: make([]int64, len(m.buckets)+1),
+		}
+		m.series[key] = series
+	}
+	series.Count++
+	if info.Error != nil {
+		series.Errors++
+	}
+	if info.RowsAffected > 0 {
+		series.Rows += info.RowsAffected
+	}
+	series.Total += info.Duration
+	if info.Duration > series.Max {
+		series.Max = info.Duration
+	}
+	series.Histogram[sort.Search(len(m.buckets), func(i int) bool { return info.Duration <= m.buckets[i] })]++
+}
+
+// Get returns a copy of the series of the table and operation
+func (m *StatementMetrics) Get(table, operation string) (StatementSeries, bool) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	series, ok := m.series[statementKey{table: table, operation: operation}]
+	if !ok {
+		return StatementSeries{}, false
+	}
+	return series.copy(), true
+}
+
+// Series returns a copy of all the series, ordered by table and operation
+func (m *StatementMetrics) Series() []StatementSeries {
+	m.l.Lock()
+	defer m.l.Unlock()
+	result := make([]StatementSeries, 0, len(m.series))
+	for _, series := range m.series {
+		result = append(result, series.copy())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Table != result[j].Table {
+			return result[i].Table < result[j].Table
+		}
+		return result[i].Operation < result[j].Operation
+	})
+	return result
+}
+
+// Reset forgets everything aggregated so far
+func (m *StatementMetrics) Reset() {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.series = make(map[statementKey]*StatementSeries)
+}
+
+// Mean the average duration of the statements
+func (s StatementSeries) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+func (s *StatementSeries) copy() StatementSeries {
+	result := *s
+	result.Histogram = append([]int64(nil), s.Histogram...)
+	return result
+}
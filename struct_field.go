@@ -41,6 +41,10 @@ func NewStructField(fromStruct reflect.StructField, toDBName string) (*StructFie
 		field.Type = field.Type.Elem()
 	}
 
+	if field.Type == versionType {
+		field.setFlag(ffIsVersion)
+	}
+
 	if !field.IsIgnored() {
 		fv := reflect.New(field.Type)
 		//checking implements scanner or time
@@ -178,6 +182,44 @@ func (f *StructField) IsEmbedOrAnon() bool {
 	return f.flags&(1<<ffIsEmbedOrAnon) != 0
 }
 
+//the value of the field, marked for the vars if it's sensitive
+func (f *StructField) varValue() interface{} {
+	if f.IsSensitive() {
+		return sensitiveVar{value: f.Value.Interface()}
+	}
+	return f.Value.Interface()
+}
+
+// IsSensitive the values of the field are not logged, see RedactionPolicy
+func (f *StructField) IsSensitive() bool {
+	return f.flags&(1<<ffIsSensitive) != 0
+}
+
+//the kind set by the `soft_delete` tag, otherwise the one which fits the type of the field
+func (f *StructField) softDeleteKind() SoftDeleteKind {
+	if kind, ok := softDeleteKinds[strings.ToUpper(f.GetStrSetting(setSoftDelete))]; ok {
+		return kind
+	}
+	switch f.Type.Kind() {
+	case reflect.Bool:
+		return SoftDeleteFlag
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return SoftDeleteUnix
+	}
+	return SoftDeleteTime
+}
+
+//the related rows are deleted along with the ones of the model, see DBCon.Delete
+func (f *StructField) cascadesDelete() bool {
+	return strings.ToUpper(f.GetStrSetting(setCascade)) == strDelete
+}
+
+// IsVersion the field is used for optimistic locking, see Version
+func (f *StructField) IsVersion() bool {
+	return f.flags&(1<<ffIsVersion) != 0
+}
+
 func (f *StructField) IsAutoIncrement() bool {
 	return f.flags&(1<<ffIsAutoincrement) != 0
 }
@@ -545,6 +587,12 @@ func (f *StructField) parseTagSettings(tag reflect.StructTag) error {
 				case tagEmbedded:
 					//we don't store this in tagSettings, mark only flag
 					f.setFlag(ffIsEmbedOrAnon)
+				case tagSensitive:
+					//we don't store this in tagSettings, mark only flag
+					f.setFlag(ffIsSensitive)
+				case tagVersion:
+					//we don't store this in tagSettings, mark only flag
+					f.setFlag(ffIsVersion)
 				default:
 					//other settings are kept in the map
 					uint8Key, ok := tagSettingMap[k]
@@ -563,6 +611,14 @@ func (f *StructField) parseTagSettings(tag reflect.StructTag) error {
 							f.tagSettings.set(setRelationKind, relMany2many)
 						case tagSize:
 							storedValue, _ = strconv.Atoi(v[1])
+						case tagCascade:
+							if len(v) < 2 || strings.ToUpper(v[1]) != strDelete {
+								return fmt.Errorf(errCascadeKind, storedValue)
+							}
+						case tagSoftDelete:
+							if _, ok := softDeleteKinds[strings.ToUpper(fmt.Sprint(storedValue))]; len(v) >= 2 && !ok {
+								return fmt.Errorf(errSoftDeleteKind, storedValue)
+							}
 						case tagAssociationForeignKey, tagForeignkey:
 							var strSlice StrSlice
 							if len(v) != 2 {
@@ -691,6 +747,12 @@ func (f StructField) String() string {
 	if f.flags&(1<<ffIsPointer) != 0 {
 		collector.add(" IsPointer")
 	}
+	if f.flags&(1<<ffIsSensitive) != 0 {
+		collector.add(" IsSensitive")
+	}
+	if f.flags&(1<<ffIsVersion) != 0 {
+		collector.add(" IsVersion")
+	}
 	collector.add("\n")
 
 	if f.tagSettings.len() > 0 {
@@ -1,17 +1,25 @@
 package tests
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
 	. "github.com/badu/reGorm"
 	pgdialect "github.com/badu/reGorm/dialects/postgres"
 	"github.com/erikstmartin/go-testdb"
 	"github.com/jinzhu/now"
+	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -429,6 +437,266 @@ func Transaction(t *testing.T) {
 	}
 }
 
+func TransactionClosure(t *testing.T) {
+	err := TestDB.Transaction(func(tx *DBCon) error {
+		if err := tx.Save(&User{Name: "transaction-closure"}).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&User{}, "name = ?", "transaction-closure").Error; err != nil {
+			t.Errorf("Should find saved record inside the transaction")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("No error should raise, got %v", err)
+	}
+	if err := TestDB.First(&User{}, "name = ?", "transaction-closure").Error; err != nil {
+		t.Errorf("Should be able to find committed record")
+	}
+
+	expected := errors.New("rollback")
+	err = TestDB.Transaction(func(tx *DBCon) error {
+		if err := tx.Save(&User{Name: "transaction-closure-rollback"}).Error; err != nil {
+			return err
+		}
+		return expected
+	})
+	if err != expected {
+		t.Errorf("Transaction should return the error of the closure, got %v", err)
+	}
+	if !TestDB.First(&User{}, "name = ?", "transaction-closure-rollback").RecordNotFound() {
+		t.Errorf("Should not find record after rollback")
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Transaction should panic again after rollback")
+			}
+		}()
+		TestDB.Transaction(func(tx *DBCon) error {
+			tx.Save(&User{Name: "transaction-closure-panic"})
+			panic("oops")
+		})
+	}()
+	if !TestDB.First(&User{}, "name = ?", "transaction-closure-panic").RecordNotFound() {
+		t.Errorf("Should not find record after panic")
+	}
+}
+
+func NestedTransaction(t *testing.T) {
+	tx := TestDB.Begin()
+	if err := tx.Save(&User{Name: "nested-transaction-1"}).Error; err != nil {
+		t.Errorf("No error should raise, got %v", err)
+	}
+
+	tx2 := tx.Begin()
+	if err := tx2.Error; err != nil {
+		t.Fatalf("Begin inside a transaction should create a savepoint, got %v", err)
+	}
+	tx2.Save(&User{Name: "nested-transaction-2"})
+	if err := tx2.Rollback().Error; err != nil {
+		t.Errorf("No error should raise when rolling back to savepoint, got %v", err)
+	}
+	if err := tx2.Commit().Error; err == nil {
+		t.Errorf("A savepoint rolled back can't be committed")
+	}
+
+	if !tx.First(&User{}, "name = ?", "nested-transaction-2").RecordNotFound() {
+		t.Errorf("Should not find record after rolling back to savepoint")
+	}
+	if err := tx.First(&User{}, "name = ?", "nested-transaction-1").Error; err != nil {
+		t.Errorf("Should find record saved before the savepoint")
+	}
+
+	err := tx.Transaction(func(tx3 *DBCon) error {
+		return tx3.Save(&User{Name: "nested-transaction-3"}).Error
+	})
+	if err != nil {
+		t.Errorf("No error should raise for nested Transaction, got %v", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		t.Errorf("No error should raise on commit, got %v", err)
+	}
+	if err := tx.Commit().Error; err == nil {
+		t.Errorf("A transaction can't be committed twice")
+	}
+
+	for _, name := range []string{"nested-transaction-1", "nested-transaction-3"} {
+		if err := TestDB.First(&User{}, "name = ?", name).Error; err != nil {
+			t.Errorf("Should find committed record %q", name)
+		}
+	}
+	if !TestDB.First(&User{}, "name = ?", "nested-transaction-2").RecordNotFound() {
+		t.Errorf("Should not find rolled back record")
+	}
+
+	//sqlite can't release a savepoint while a write statement is in progress
+	if TestDB.Dialect().GetName() == "sqlite3" {
+		tx = TestDB.Begin()
+		var rows *sql.Rows
+		err = tx.Transaction(func(tx4 *DBCon) error {
+			rows, err = tx4.Raw("INSERT INTO users (name) VALUES (?), (?) RETURNING id", "nested-transaction-4", "nested-transaction-4").Rows()
+			if err == nil {
+				rows.Next()
+			}
+			return err
+		})
+		if err == nil || strings.Contains(err.Error(), ErrInvalidTransaction.Error()) {
+			t.Errorf("A savepoint which can't be released should be rolled back to, got %v", err)
+		}
+		if rows != nil {
+			rows.Close()
+		}
+		if !tx.First(&User{}, "name = ?", "nested-transaction-4").RecordNotFound() {
+			t.Errorf("Should not find the record of a savepoint which can't be released")
+		}
+		tx.Save(&User{Name: "nested-transaction-5"})
+		if err := tx.Commit().Error; err != nil {
+			t.Errorf("The transaction should go on after the savepoint is rolled back to, got %v", err)
+		}
+		if err := TestDB.First(&User{}, "name = ?", "nested-transaction-5").Error; err != nil {
+			t.Errorf("Should find the record committed after the savepoint is rolled back to")
+		}
+	}
+}
+
+func TransactionOptions(t *testing.T) {
+	tx := TestDB.BeginTx(&sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err := tx.Error; err != nil {
+		t.Fatalf("No error should raise when beginning a transaction with options, got %v", err)
+	}
+	if err := tx.Save(&User{Name: "transaction-options"}).Error; err != nil {
+		t.Errorf("No error should raise, got %v", err)
+	}
+	tx.Commit()
+	if err := TestDB.First(&User{}, "name = ?", "transaction-options").Error; err != nil {
+		t.Errorf("Should be able to find committed record")
+	}
+
+	conn := TestDB.Unscoped().SetTxOptions(&sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err := conn.Save(&User{Name: "transaction-default-options"}).Error; err != nil {
+		t.Errorf("No error should raise when saving with default transaction options, got %v", err)
+	}
+	if err := TestDB.First(&User{}, "name = ?", "transaction-default-options").Error; err != nil {
+		t.Errorf("Should be able to find record saved with default transaction options")
+	}
+
+	if dialect := os.Getenv("GORM_DIALECT"); dialect == "" || dialect == "sqlite" {
+		// sqlite ignores the read only option
+		return
+	}
+	readOnly := TestDB.BeginTx(&sql.TxOptions{ReadOnly: true})
+	if err := readOnly.Save(&User{Name: "transaction-read-only"}).Error; err == nil {
+		t.Errorf("Should not be able to write inside a read only transaction")
+	}
+	readOnly.Rollback()
+}
+
+func DryRun(t *testing.T) {
+	var users []User
+	dry := TestDB.DryRun()
+	dry.Where("name = ?", "dry_run_user").Find(&users)
+	product := Product{Code: "dry_run_product", Price: 10}
+	if err := dry.Save(&product).Error; err != nil {
+		t.Errorf("No error should happen when saving in dry run mode, got %v", err)
+	}
+
+	statements := dry.Statements()
+	//Product's AfterCreate updates a column
+	if len(statements) != 3 {
+		t.Fatalf("Three statements should be captured, got %d", len(statements))
+	}
+	if !strings.HasPrefix(statements[0].SQL, "SELECT") || !reflect.DeepEqual(statements[0].Vars, []interface{}{"dry_run_user"}) {
+		t.Errorf("The query should be captured with its variables, got %v %v", statements[0].SQL, statements[0].Vars)
+	}
+	if !strings.HasPrefix(statements[1].SQL, "INSERT INTO") || !strings.HasPrefix(statements[2].SQL, "UPDATE") {
+		t.Errorf("The insert and the update from the hook should be captured, got %v %v", statements[1].SQL, statements[2].SQL)
+	}
+	if product.BeforeSaveCallTimes != 1 || product.AfterSaveCallTimes != 1 {
+		t.Errorf("Hooks should run in dry run mode, %v", product.GetCallTimes())
+	}
+	if !TestDB.First(&Product{}, "code = ?", "dry_run_product").RecordNotFound() {
+		t.Errorf("Nothing should be written in dry run mode")
+	}
+
+	sql, vars := TestDB.ToSQL(func(tx *DBCon) *DBCon {
+		return tx.Model(&User{}).Where("name = ?", "dry_run_user").Update("age", 20)
+	})
+	if !strings.HasPrefix(sql, "UPDATE") || len(vars) != 3 || vars[2] != "dry_run_user" {
+		t.Errorf("ToSQL should return the update statement, got %v %v", sql, vars)
+	}
+
+	if TestDB.DryRun().HasTable(&Product{}) || !TestDB.HasTable(&Product{}) {
+		t.Errorf("The dialect of a dry run session should not query the database")
+	}
+
+	//postgres reads the primary key with RETURNING, which gets no row in dry run mode
+	pg, err := Open("postgres", TestDB.DB())
+	if err != nil {
+		t.Fatalf("No error should happen when opening a postgres dialect, got %v", err)
+	}
+	dryPg := pg.DryRun()
+	returning := Product{Code: "dry_run_returning", Price: 10}
+	if err := dryPg.Create(&returning).Error; err != nil {
+		t.Errorf("No error should happen when creating with RETURNING in dry run mode, got %v", err)
+	}
+	returnings := []Product{{Code: "dry_run_returning_a"}, {Code: "dry_run_returning_b"}}
+	if err := dryPg.Create(&returnings).Error; err != nil {
+		t.Errorf("No error should happen when creating a slice with RETURNING in dry run mode, got %v", err)
+	}
+	statements = dryPg.Statements()
+	if len(statements) == 0 || !strings.Contains(statements[0].SQL, "RETURNING") {
+		t.Errorf("The insert should be captured with its RETURNING clause, got %v", statements)
+	}
+	if returning.AfterSaveCallTimes != 1 || returnings[1].AfterSaveCallTimes != 1 {
+		t.Errorf("The after hooks should run after a RETURNING insert in dry run mode, %v", returning.GetCallTimes())
+	}
+
+	if err := TestDB.Save(&Product{Code: "dry_run_product"}).Error; err != nil {
+		t.Errorf("The connection should not be affected by a dry run session, got %v", err)
+	}
+	if TestDB.First(&Product{}, "code = ?", "dry_run_product").Error != nil {
+		t.Errorf("The connection should still write after a dry run session")
+	}
+}
+
+func WithContext(t *testing.T) {
+	u := User{Name: "context_user"}
+	if err := TestDB.WithContext(context.Background()).Save(&u).Error; err != nil {
+		t.Errorf("No error should raise when saving with a live context, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var users []User
+	if err := TestDB.WithContext(ctx).Where("name = ?", u.Name).Find(&users).Error; err == nil {
+		t.Errorf("Should get an error when querying with a cancelled context")
+	}
+
+	if err := TestDB.WithContext(ctx).Save(&User{Name: "context_user_cancelled"}).Error; err == nil {
+		t.Errorf("Should get an error when creating with a cancelled context")
+	}
+
+	if !TestDB.Where("name = ?", "context_user_cancelled").First(&User{}).RecordNotFound() {
+		t.Errorf("Should not create record when context is cancelled")
+	}
+
+	var seen context.Context
+	type ctxKey struct{}
+	callbackDB := TestDB.WithContext(context.WithValue(context.Background(), ctxKey{}, "value"))
+	callbackDB.Callback().Query().Register("test:context", func(scope *Scope) {
+		seen = scope.Context()
+	})
+	callbackDB.First(&User{}, "name = ?", u.Name)
+	callbackDB.Callback().Query().Remove("test:context")
+	if seen == nil || seen.Value(ctxKey{}) != "value" {
+		t.Errorf("Scope should expose the connection's context to callbacks")
+	}
+}
+
 func Row(t *testing.T) {
 	user1 := User{Name: "RowUser1", Age: 1, Birthday: parseTime("2000-1-1")}
 	user2 := User{Name: "RowUser2", Age: 10, Birthday: parseTime("2010-1-1")}
@@ -818,3 +1086,469 @@ func OpenWithOneParameter(t *testing.T) {
 		t.Error("Open with one parameter returned err as nil")
 	}
 }
+
+func LeveledLogger(t *testing.T) {
+	recorder := &RecordingLogger{}
+	db := TestDB.Debug()
+	db.SetLogger(recorder)
+
+	user := User{Name: "leveled_logger_user", Age: 1}
+	db.Save(&user)
+	var insert *LogEntry
+	traces := recorder.Get("trace")
+	for i := range traces {
+		if strings.HasPrefix(traces[i].SQL, "INSERT INTO") {
+			insert = &traces[i]
+		}
+	}
+	if insert == nil || insert.Rows != 1 || insert.Err != nil {
+		t.Fatalf("The insert should be traced with its affected rows, got %v", traces)
+	}
+	db.Log("leveled", "logger")
+	if infos := recorder.Get("info"); len(infos) != 1 || infos[0].Msg != "leveled logger" {
+		t.Errorf("Log should write at info level, got %v", infos)
+	}
+	db.Where("unknown_column = ?", 1).Find(&[]User{})
+	if errs := recorder.Get("error"); len(errs) != 1 {
+		t.Errorf("The failing statement should be logged as an error, got %v", errs)
+	}
+
+	recorder = &RecordingLogger{}
+	db.SetLogger(recorder)
+	db.SetLogMode(LogOff)
+	db.First(&User{}, user.Id)
+	db.Where("unknown_column = ?", 1).Find(&[]User{})
+	traces = recorder.Get("trace")
+	if len(traces) != 1 || traces[0].Err == nil || !strings.Contains(traces[0].SQL, "unknown_column") {
+		t.Errorf("With log mode off only the failing statement should be traced, got %v", traces)
+	}
+
+	var buffer bytes.Buffer
+	db.SetLogger(NewLogger(log.New(&buffer, "", 0), false))
+	db.SetLogMode(LogVerbose)
+	db.First(&User{}, user.Id)
+	if output := buffer.String(); strings.Contains(output, "\033[") || !strings.Contains(output, "[rows:1]") {
+		t.Errorf("The colourless logger should write plain lines, got %q", output)
+	}
+
+	buffer.Reset()
+	db.SetLogger(SlogLogger{Logger: slog.New(slog.NewTextHandler(&buffer, nil))})
+	db.First(&User{}, user.Id)
+	if output := buffer.String(); !strings.Contains(output, "msg=statement") || !strings.Contains(output, "rows=") {
+		t.Errorf("The slog adapter should log the statement, got %q", output)
+	}
+}
+
+func SlowQueryLog(t *testing.T) {
+	recorder := &RecordingLogger{}
+	db := TestDB.Unscoped()
+	db.SetLogger(recorder)
+	db.SetLogMode(LogOff)
+
+	db.SetSlowThreshold(time.Hour)
+	db.Save(&User{Name: "slow_query_user", Age: 1})
+	if warns := recorder.Get("warn"); len(warns) != 0 {
+		t.Errorf("No statement should be slower than the threshold, got %v", warns)
+	}
+
+	db.SetSlowThreshold(time.Nanosecond)
+	db.Model(&User{}).Where("name = ?", "slow_query_user").Update("age", 2)
+	db.Where("name = ?", "slow_query_user").First(&User{})
+	warns := recorder.Get("warn")
+	if len(warns) != 2 {
+		t.Fatalf("The update and the query should be reported as slow, got %v", warns)
+	}
+	sql, _ := LogArg(warns[0].Args, "sql")
+	rows, _ := LogArg(warns[0].Args, "rows")
+	source, _ := LogArg(warns[0].Args, "source")
+	if warns[0].Msg != "slow query" || !strings.HasPrefix(sql.(string), "UPDATE") || rows != int64(1) {
+		t.Errorf("The slow update should be reported with its sql and rows, got %v", warns[0])
+	}
+	if !strings.Contains(source.(string), "basic_test.go") {
+		t.Errorf("The caller should be reported as source, got %v", source)
+	}
+	if vars, _ := LogArg(warns[1].Args, "vars"); !reflect.DeepEqual(vars, []interface{}{"slow_query_user"}) {
+		t.Errorf("The slow query should be reported with its vars, got %v", warns[1])
+	}
+	if rows, _ := LogArg(warns[1].Args, "rows"); rows != int64(1) {
+		t.Errorf("The slow query should be reported with the rows read, got %v", warns[1])
+	}
+	if traces := recorder.Get("trace"); len(traces) != 0 {
+		t.Errorf("With log mode off statements should not be traced, got %v", traces)
+	}
+
+	db.SetSlowThreshold(0)
+	db.Where("name = ?", "slow_query_user").First(&User{})
+	if warns := recorder.Get("warn"); len(warns) != 2 {
+		t.Errorf("A zero threshold should disable the slow query log, got %v", warns)
+	}
+}
+
+func StatementMetricsObserver(t *testing.T) {
+	metrics := NewStatementMetrics()
+	db := TestDB.Unscoped()
+	db.SetStatementObserver(metrics)
+
+	user := User{Name: "statement_metrics_user", Age: 1}
+	db.Save(&user)
+	db.Where("name = ?", "statement_metrics_user").Find(&[]User{})
+	db.Model(&user).Update("age", 2)
+	db.Table("users").Where("name = ?", "statement_metrics_user").Select("name").Row()
+	db.Exec("UPDATE users SET age = ? WHERE name = ?", 3, "statement_metrics_user")
+	db.Delete(&user)
+	db.Table("statement_metrics_missing").Find(&[]User{})
+	var count int
+	db.Table("statement_metrics_missing_count").Count(&count)
+
+	for _, operation := range []string{OperationCreate, OperationQuery, OperationUpdate, OperationDelete, OperationRow} {
+		series, ok := metrics.Get("users", operation)
+		if !ok || series.Count == 0 {
+			t.Errorf("The %s statements of users should be aggregated, got %v", operation, metrics.Series())
+			continue
+		}
+		var histogram int64
+		for _, count := range series.Histogram {
+			histogram += count
+		}
+		if histogram != series.Count || len(series.Histogram) != len(series.Buckets)+1 {
+			t.Errorf("The histogram should count every %s statement, got %v", operation, series)
+		}
+	}
+	if series, _ := metrics.Get("users", OperationCreate); series.Rows != 1 {
+		t.Errorf("The affected rows should be aggregated, got %v", series)
+	}
+	if series, ok := metrics.Get("", OperationRaw); !ok || series.Count != 1 || series.Rows != 1 {
+		t.Errorf("The raw statement should be aggregated without a table, got %v", metrics.Series())
+	}
+	if series, ok := metrics.Get("statement_metrics_missing", OperationQuery); !ok || series.Errors != 1 {
+		t.Errorf("The failing query should be counted as an error, got %v", metrics.Series())
+	}
+	if series, ok := metrics.Get("statement_metrics_missing_count", OperationRow); !ok || series.Errors != 1 {
+		t.Errorf("The failing single row query should be counted as an error, got %v", metrics.Series())
+	}
+
+	metrics.Reset()
+	TestDB.Where("name = ?", "statement_metrics_user").Find(&[]User{})
+	if series := metrics.Series(); len(series) != 0 {
+		t.Errorf("The observer should not be set on the connection it was cloned from, got %v", series)
+	}
+}
+
+func TracingSpans(t *testing.T) {
+	recorder := NewSpanRecorder()
+	db := TestDB.Unscoped()
+	db.SetTracer(recorder)
+
+	user := User{
+		Name:           "tracing_spans_user",
+		BillingAddress: Address{Address1: "tracing billing address"},
+		Emails:         []Email{{Email: "tracing_spans@example.com"}},
+	}
+	if err := db.Save(&user).Error; err != nil {
+		t.Fatalf("No error should happen when saving with a tracer, got %v", err)
+	}
+	roots := recorder.Roots()
+	if len(roots) != 1 || roots[0].Name != SpanSave || roots[0].Attributes["table"] != "users" || !roots[0].Ended {
+		t.Fatalf("Save should be the only root span, got %v", roots)
+	}
+	billing := roots[0].Find(SpanAssociation)
+	if billing == nil || billing.Attributes["field"] != "BillingAddress" {
+		t.Fatalf("The belongs to association save should be traced, got %v", roots[0].Children)
+	}
+	if insert := billing.Find(SpanStatement); insert == nil || insert.Attributes["table"] != "addresses" || insert.Attributes["rows"] != int64(1) {
+		t.Errorf("The address insert should be a child of the association span, got %v", billing.Children)
+	}
+	var emails *RecordedSpan
+	for _, child := range roots[0].Children {
+		if child.Name == SpanAssociation && child.Attributes["field"] == "Emails" {
+			emails = child
+		}
+	}
+	if emails == nil || emails.Find(SpanSave) == nil || emails.Find(SpanStatement) == nil {
+		t.Errorf("The has many association save should contain the saves of the emails, got %v", roots[0].Children)
+	}
+	for _, span := range recorder.Spans() {
+		if !span.Ended {
+			t.Errorf("Every span should be ended, %v is not", span.Name)
+		}
+	}
+
+	recorder.Reset()
+	db.Preload("Emails").Where("name = ?", "tracing_spans_user").First(&User{})
+	db.Exec("UPDATE users SET age = ? WHERE name = ?", 1, "tracing_spans_user")
+	roots = recorder.Roots()
+	if len(roots) != 2 || roots[0].Name != SpanFirst || roots[1].Name != SpanExec {
+		t.Fatalf("First and Exec should be root spans, got %v", roots)
+	}
+	if preload := roots[0].Find(SpanPreload); preload == nil || preload.Attributes["field"] != "Emails" || preload.Find(SpanStatement) == nil {
+		t.Errorf("The preload should be traced with its statement, got %v", roots[0].Children)
+	}
+	if statement := roots[1].Find(SpanStatement); statement == nil || statement.Attributes["operation"] != OperationRaw {
+		t.Errorf("The raw statement should be a child of Exec, got %v", roots[1].Children)
+	}
+
+	recorder.Reset()
+	db.Table("tracing_spans_missing").Find(&[]User{})
+	if roots = recorder.Roots(); len(roots) != 1 || roots[0].Err == nil || roots[0].Find(SpanStatement).Err == nil {
+		t.Errorf("The failing operation and statement should end with the error, got %v", roots)
+	}
+
+	recorder.Reset()
+	TestDB.First(&User{}, user.Id)
+	if spans := recorder.Spans(); len(spans) != 0 {
+		t.Errorf("The tracer should not be set on the connection it was cloned from, got %v", spans)
+	}
+
+	db.SetTracer(nil)
+	if err := db.First(&User{}, user.Id).Error; err != nil || len(recorder.Spans()) != 0 {
+		t.Errorf("Setting a nil tracer should restore the tracer which traces nothing, got %v", err)
+	}
+	if ctx, span := (NoopTracer{}).StartSpan(context.Background(), SpanFind); ctx != context.Background() || span == nil {
+		t.Errorf("The noop tracer should return the context unchanged, with a span")
+	}
+}
+
+func RedactedLogs(t *testing.T) {
+	TestDB.DropTable(&Credential{})
+	TestDB.AutoMigrate(&Credential{})
+
+	recorder := &RecordingLogger{}
+	db := TestDB.Debug()
+	db.SetLogger(recorder)
+
+	credential := Credential{Login: "redacted_login", Password: "secret_password", Token: "secret_token"}
+	db.Save(&credential)
+	db.Model(&credential).Update("password", "new_secret_password")
+	db.Where(&Credential{Login: "redacted_login", Password: "new_secret_password"}).First(&Credential{})
+	db.SetSlowThreshold(time.Nanosecond)
+	db.Model(&credential).Updates(Credential{Token: "new_secret_token"})
+	db.SetSlowThreshold(0)
+
+	traces := recorder.Get("trace")
+	if len(traces) != 4 {
+		t.Fatalf("Four statements should be traced, got %v", traces)
+	}
+	for _, trace := range traces {
+		logged := FormatSQL(trace.SQL, trace.Vars)
+		if strings.Contains(logged, "secret") {
+			t.Errorf("Sensitive values should not be logged, got %v", logged)
+		}
+		if !strings.Contains(logged, "'***'") {
+			t.Errorf("Sensitive values should be logged as '***', got %v", logged)
+		}
+	}
+	if !strings.Contains(FormatSQL(traces[0].SQL, traces[0].Vars), "redacted_login") {
+		t.Errorf("Values which are not sensitive should be logged, got %v", traces[0])
+	}
+	warns := recorder.Get("warn")
+	if len(warns) == 0 {
+		t.Fatalf("The slow update should be reported")
+	}
+	if vars, _ := LogArg(warns[0].Args, "vars"); strings.Contains(fmt.Sprint(vars), "secret") {
+		t.Errorf("Sensitive values should not be reported in slow queries, got %v", vars)
+	}
+
+	var found Credential
+	TestDB.First(&found, credential.Id)
+	if found.Password != "new_secret_password" || found.Token != "new_secret_token" {
+		t.Errorf("Sensitive values should be written unchanged, got %v", found)
+	}
+
+	recorder = &RecordingLogger{}
+	db.SetLogger(recorder)
+	observer := &VarsObserver{}
+	db.SetStatementObserver(observer)
+	inline := Credential{Login: "inline_login"}
+	db.Save(&inline)
+	db.Model(&inline).UpdateColumn("login", Sensitive("inline_secret_login"))
+	db.Table("credentials").Where("id = ?", inline.Id).UpdateColumn("token", Sensitive("inline_secret_token"))
+	db.Model(&inline).UpdateColumn(map[string]interface{}{"password": "map_secret_password"})
+	if err := db.Where("token IN (?)", Sensitive([]string{"inline_secret_token"})).First(&Credential{}).Error; err != nil {
+		t.Errorf("A sensitive slice should be expanded, got %v", err)
+	}
+	db.SetStatementObserver(nil)
+	if len(observer.Vars) != 5 {
+		t.Errorf("Five statements should be observed, got %v", observer.Vars)
+	}
+	for _, vars := range observer.Vars {
+		if strings.Contains(fmt.Sprint(vars), "secret") {
+			t.Errorf("Sensitive values should not be seen by the observer, got %v", vars)
+		}
+	}
+	for _, trace := range recorder.Get("trace") {
+		if logged := FormatSQL(trace.SQL, trace.Vars); strings.Contains(logged, "secret") {
+			t.Errorf("Values marked as sensitive should not be logged, got %v", logged)
+		}
+	}
+	var inlineFound Credential
+	TestDB.First(&inlineFound, inline.Id)
+	if inlineFound.Login != "inline_secret_login" || inlineFound.Token != "inline_secret_token" || inlineFound.Password != "map_secret_password" {
+		t.Errorf("Values marked as sensitive should be written unchanged, got %v", inlineFound)
+	}
+
+	recorder = &RecordingLogger{}
+	db.SetLogger(recorder)
+	db.SetRedaction(RedactAll)
+	db.Where("login = ?", "redacted_login").First(&Credential{})
+	if traces = recorder.Get("trace"); len(traces) != 1 || len(traces[0].Vars) != 0 || !strings.Contains(FormatSQL(traces[0].SQL, traces[0].Vars), "?") {
+		t.Errorf("Only placeholders should be logged, got %v", traces)
+	}
+
+	recorder = &RecordingLogger{}
+	db.SetLogger(recorder)
+	db.SetRedaction(RedactNone)
+	db.Where(&Credential{Password: "new_secret_password"}).First(&Credential{})
+	if traces = recorder.Get("trace"); len(traces) != 1 || !strings.Contains(FormatSQL(traces[0].SQL, traces[0].Vars), "new_secret_password") {
+		t.Errorf("Every value should be logged, got %v", traces)
+	}
+	db.SetRedaction(RedactSensitive)
+
+	TestDB.DropTable(&SecretKeyed{})
+	TestDB.AutoMigrate(&SecretKeyed{})
+	observer = &VarsObserver{}
+	db.SetStatementObserver(observer)
+	db.Create(&SecretKeyed{Key: "key_secret_a", Name: "keyed"})
+	db.Create(&SecretKeyed{Key: "key_secret_b", Name: "keyed"})
+	var keys []string
+	db.Where("name = ?", "keyed").FindInBatches(&[]SecretKeyed{}, 1, func(tx *DBCon, batch int) error {
+		keys = append(keys, "batch")
+		return nil
+	})
+	var keyed []SecretKeyed
+	db.Where([]string{"key_secret_a", "key_secret_b"}).Find(&keyed)
+	db.Not([]string{"key_secret_a"}).Find(&[]SecretKeyed{})
+	if err := db.Where(map[string]interface{}{"token": "inline_secret_token"}).First(&Credential{}).Error; err != nil {
+		t.Errorf("A sensitive map condition should find the row, got %v", err)
+	}
+	db.Not(map[string]interface{}{"token": "inline_secret_token"}).Find(&[]Credential{})
+	db.SetStatementObserver(nil)
+	if len(keys) != 2 || len(keyed) != 2 {
+		t.Errorf("The rows should be found by their sensitive keys, got %d batches and %v", len(keys), keyed)
+	}
+	for _, vars := range observer.Vars {
+		if strings.Contains(fmt.Sprint(vars), "secret") {
+			t.Errorf("Sensitive keys and conditions should not be seen by the observer, got %v", vars)
+		}
+	}
+}
+
+func OperationResult(t *testing.T) {
+	TestDB.DropTable(&ConcurrentItem{})
+	TestDB.AutoMigrate(&ConcurrentItem{})
+
+	item := ConcurrentItem{Name: "result"}
+	created := TestDB.Create(&item)
+	result := created.Result()
+	if result.Error != nil || result.RowsAffected != 1 || result.LastInsertID == 0 || result.LastInsertID != item.Id {
+		t.Errorf("The result of create should carry the inserted id, got %#v", result)
+	}
+	if !strings.HasPrefix(result.SQL, "INSERT INTO") || len(result.Vars) != 2 {
+		t.Errorf("The result of create should carry the executed statement, got %v %v", result.SQL, result.Vars)
+	}
+	result.Vars[0] = "changed"
+	if created.Result().Vars[0] == "changed" {
+		t.Errorf("The result should be a copy")
+	}
+
+	items := []ConcurrentItem{{Name: "result_a"}, {Name: "result_b"}}
+	if result = TestDB.Create(&items).Result(); result.RowsAffected != 2 || result.LastInsertID != items[1].Id {
+		t.Errorf("The result of a batch create should carry the last inserted id, got %#v", result)
+	}
+
+	if result = TestDB.Model(&item).Update("name", "result_updated").Result(); result.RowsAffected != 1 || result.LastInsertID != 0 || !strings.HasPrefix(result.SQL, "UPDATE") {
+		t.Errorf("The result of update should carry its own statement, got %#v", result)
+	}
+
+	var found []ConcurrentItem
+	if result = TestDB.Where("name LIKE ?", "result%").Find(&found).Result(); result.Error != nil || result.RowsAffected != 3 || !strings.HasPrefix(result.SQL, "SELECT") {
+		t.Errorf("The result of find should carry the found rows, got %#v", result)
+	}
+
+	if result = TestDB.First(&ConcurrentItem{}, "name = ?", "missing").Result(); result.Error != ErrRecordNotFound || result.RowsAffected != 0 {
+		t.Errorf("The result should carry the error, got %#v", result)
+	}
+	if TestDB.Error != nil || TestDB.RowsAffected != 0 || TestDB.Result().SQL != "" {
+		t.Errorf("The operations should not change the shared connection, got %#v", TestDB.Result())
+	}
+}
+
+func ConcurrentOperations(t *testing.T) {
+	const workers, iterations = 8, 20
+	db := TestDB
+	if TestDB.Dialect().GetName() == "sqlite3" {
+		//the in memory database of the other tests locks whole tables, a file waits for the locks instead
+		var err error
+		db, err = Open("sqlite3", filepath.Join(t.TempDir(), "concurrent.db")+"?_busy_timeout=10000&_txlock=immediate&_journal_mode=WAL")
+		if err != nil {
+			t.Fatalf("No error should happen when opening, got %v", err)
+		}
+		defer db.Close()
+	}
+	db.DropTableIfExists(&ConcurrentItem{})
+	db.AutoMigrate(&ConcurrentItem{})
+
+	shared := db.Model(&ConcurrentItem{}).Where("worker >= ?", 0)
+	errs := make(chan error, workers*iterations)
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			//built by whichever worker needs it first, with its relations
+			for _, field := range db.NewScope(&ConstrainedOwner{}).GetModelStruct().StructFields() {
+				if field.StructName == "Items" && len(field.GetForeignFieldNames()) != 1 {
+					errs <- fmt.Errorf("relation %v : %v", field.StructName, field.GetForeignFieldNames())
+					return
+				}
+			}
+			for i := 0; i < iterations; i++ {
+				item := ConcurrentItem{Name: fmt.Sprintf("item_%d_%d", worker, i), Worker: worker}
+				if result := db.Create(&item).Result(); result.Error != nil || result.RowsAffected != 1 || result.LastInsertID != item.Id {
+					errs <- fmt.Errorf("create %v : %#v", item.Name, result)
+					return
+				}
+
+				var found ConcurrentItem
+				if result := shared.Where("name = ?", item.Name).First(&found).Result(); result.Error != nil || found.Id != item.Id {
+					errs <- fmt.Errorf("first %v : %#v", item.Name, result)
+					return
+				}
+				if result := shared.Where("name = ?", "missing").First(&ConcurrentItem{}).Result(); result.Error != ErrRecordNotFound {
+					errs <- fmt.Errorf("first missing : %#v", result)
+					return
+				}
+
+				if result := db.Model(&found).Update("name", item.Name+"_updated").Result(); result.Error != nil || result.RowsAffected != 1 {
+					errs <- fmt.Errorf("update %v : %#v", item.Name, result)
+					return
+				}
+
+				if i%2 == 1 {
+					if result := db.Delete(&found).Result(); result.Error != nil || result.RowsAffected != 1 {
+						errs <- fmt.Errorf("delete %v : %#v", item.Name, result)
+						return
+					}
+				}
+
+				var items []ConcurrentItem
+				if result := shared.Where("worker = ?", worker).Find(&items).Result(); result.Error != nil || len(items) != i/2+1 || result.RowsAffected != int64(len(items)) {
+					errs <- fmt.Errorf("find worker %d : %d items, %#v", worker, len(items), result)
+					return
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var count int
+	if err := shared.Count(&count).Error; err != nil || count != workers*iterations/2 {
+		t.Errorf("Every worker should keep half of its items, got %d (%v)", count, err)
+	}
+	if db.Error != nil || shared.Error != nil || shared.RowsAffected != 0 {
+		t.Errorf("The shared connections should be left untouched, got %v %v", db.Error, shared.Error)
+	}
+}
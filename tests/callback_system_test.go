@@ -2,6 +2,8 @@ package tests
 
 import (
 	. "github.com/badu/reGorm"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -91,3 +93,101 @@ func RemoveCallback(t *testing.T) {
 		t.Errorf("remove callback")
 	}
 }
+
+func CallbacksOrder(t *testing.T) {
+	order, err := TestDB.Callback().Describe()
+	if err != nil {
+		t.Errorf("The default callbacks should be valid, got %v", err)
+	}
+	if !reflect.DeepEqual(order["create"], []string{"gorm:begin_transaction", "gorm:before_create", "gorm:save_before_associations",
+		"gorm:update_time_stamp_when_create", "gorm:create", "gorm:force_reload_after_create", "gorm:save_after_associations",
+		"gorm:after_create", "gorm:commit_or_rollback_transaction"}) {
+		t.Errorf("Unexpected create callbacks, got %v", order["create"])
+	}
+	if !reflect.DeepEqual(order["query"], []string{"gorm:query", "gorm:preload", "gorm:after_query"}) {
+		t.Errorf("Unexpected query callbacks, got %v", order["query"])
+	}
+	if len(order["row"]) != 0 {
+		t.Errorf("There should be no row callbacks, got %v", order["row"])
+	}
+	if err := TestDB.Callback().Create().After("gorm:commit_or_rollback_transaction").Before("gorm:begin_transaction").Register("test:contradiction", create); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("A callback which contradicts the built-in steps should be a cycle, got %v", err)
+	}
+	if order, _ = TestDB.Callback().Describe(); order["create"][0] != "gorm:begin_transaction" {
+		t.Errorf("The rejected callback should not change the built-in steps, got %v", order["create"])
+	}
+
+	var callback = &Callbacks{}
+	if err := callback.Create().Register("", create); err == nil {
+		t.Errorf("A callback without name should not be registered")
+	}
+	callback.Create().Register("create", create)
+	if err := callback.Create().Register("create", replaceCreate); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("A duplicated callback should not be registered, got %v", err)
+	}
+	if err := callback.Update().Register("create", create); err != nil {
+		t.Errorf("The same name can be used by another kind, got %v", err)
+	}
+
+	callback.Create().Before("after_create1").Register("before_create1", beforeCreate1)
+	if order, err = callback.Describe(); err == nil || !strings.Contains(err.Error(), `"after_create1"`) {
+		t.Errorf("A callback which is not registered should be reported, got %v", err)
+	}
+	callback.Create().After("create").Register("after_create1", afterCreate1)
+	if order, err = callback.Describe(); err != nil || !reflect.DeepEqual(order["create"], []string{"create", "before_create1", "after_create1"}) {
+		t.Errorf("The callbacks should be ordered once registered, got %v (%v)", order["create"], err)
+	}
+
+	if err := callback.Create().After("after_create1").Before("before_create1").Register("after_create2", afterCreate2); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("A cycle should be an error, got %v", err)
+	}
+	if err := callback.Create().After("after_create2").Register("after_create2", afterCreate2); err == nil {
+		t.Errorf("A callback can't run after itself")
+	}
+	if order, _ = callback.Describe(); !reflect.DeepEqual(order["create"], []string{"create", "before_create1", "after_create1"}) {
+		t.Errorf("The rejected callbacks should not be registered, got %v", order["create"])
+	}
+	if !equalFuncs(callback.GetCreates(), []string{"create", "beforeCreate1", "afterCreate1"}) {
+		t.Errorf("The rejected callbacks should not be called")
+	}
+
+	if err := callback.Create().Replace("missing", create); err == nil {
+		t.Errorf("Replacing a callback which is not registered should be an error")
+	}
+	if err := callback.Create().Remove("missing"); err == nil {
+		t.Errorf("Removing a callback which is not registered should be an error")
+	}
+	if err := callback.Create().Remove("create"); err != nil {
+		t.Errorf("No error should happen when removing, got %v", err)
+	}
+	if order, err = callback.Describe(); err == nil || !reflect.DeepEqual(order["create"], []string{"before_create1", "after_create1"}) {
+		t.Errorf("The callbacks after a removed one should be reported, got %v (%v)", order["create"], err)
+	}
+	if order["update"][0] != "create" {
+		t.Errorf("Removing should not change other kinds, got %v", order["update"])
+	}
+	if err := callback.Create().Remove("create"); err == nil {
+		t.Errorf("Removing a removed callback should be an error")
+	}
+	if err := callback.Create().Replace("create", replaceCreate); err != nil {
+		t.Errorf("A removed callback can be replaced, got %v", err)
+	}
+	if order, err = callback.Describe(); err != nil || !reflect.DeepEqual(order["create"], []string{"create", "before_create1", "after_create1"}) {
+		t.Errorf("The replaced callback should be back in its place, got %v (%v)", order["create"], err)
+	}
+	if !equalFuncs(callback.GetCreates(), []string{"replaceCreate", "beforeCreate1", "afterCreate1"}) {
+		t.Errorf("The replaced callback should be called")
+	}
+
+	callback = &Callbacks{}
+	callback.Create().Register("p0", create)
+	if err := callback.Create().Before("p0").After("p2").Register("p1", beforeCreate1); err == nil || !strings.Contains(err.Error(), `"p2"`) {
+		t.Errorf("A callback which is not registered yet should be reported, got %v", err)
+	}
+	if err := callback.Create().Register("p2", afterCreate1); err != nil {
+		t.Errorf("Callbacks without cycle should be registered, got %v", err)
+	}
+	if order, err = callback.Describe(); err != nil || !reflect.DeepEqual(order["create"], []string{"p2", "p1", "p0"}) {
+		t.Errorf("The callbacks should run after the ones they wait for, got %v (%v)", order["create"], err)
+	}
+}
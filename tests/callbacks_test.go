@@ -1,8 +1,10 @@
 package tests
 
 import (
+	. "github.com/badu/reGorm"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func RunCallbacks(t *testing.T) {
@@ -107,3 +109,241 @@ func CallbacksWithErrors(t *testing.T) {
 		t.Errorf("Record shouldn't be deleted because of an error happened in after delete callback")
 	}
 }
+
+func TransactionCallbacks(t *testing.T) {
+	p := Product{Code: "commit_callback", Price: 100}
+	TestDB.Save(&p)
+	if p.AfterCommitCallTimes != 1 || p.AfterRollbackCallTimes != 0 {
+		t.Errorf("AfterCommit should be called once the implicit transaction commits, %d %d", p.AfterCommitCallTimes, p.AfterRollbackCallTimes)
+	}
+
+	p2 := Product{Code: "after_save_error", Price: 100}
+	TestDB.Save(&p2)
+	if p2.AfterCommitCallTimes != 0 || p2.AfterRollbackCallTimes != 1 {
+		t.Errorf("AfterRollback should be called once the implicit transaction rolls back, %d %d", p2.AfterCommitCallTimes, p2.AfterRollbackCallTimes)
+	}
+
+	committed := false
+	p3 := Product{Code: "commit_callback_tx", Price: 100}
+	tx := TestDB.Begin()
+	tx.Save(&p3)
+	tx.OnCommit(func() { committed = true })
+	if p3.AfterCommitCallTimes != 0 || committed {
+		t.Errorf("AfterCommit and OnCommit shouldn't be called before the transaction commits")
+	}
+	tx.Commit()
+	if p3.AfterCommitCallTimes != 1 || p3.AfterRollbackCallTimes != 0 || !committed {
+		t.Errorf("AfterCommit and OnCommit should be called after the transaction commits, %d %d %v", p3.AfterCommitCallTimes, p3.AfterRollbackCallTimes, committed)
+	}
+
+	committed = false
+	p4 := Product{Code: "rollback_callback_tx", Price: 100}
+	tx = TestDB.Begin()
+	tx.Save(&p4)
+	tx.OnCommit(func() { committed = true })
+	tx.Rollback()
+	if p4.AfterCommitCallTimes != 0 || p4.AfterRollbackCallTimes != 1 || committed {
+		t.Errorf("Only AfterRollback should be called after the transaction rolls back, %d %d %v", p4.AfterCommitCallTimes, p4.AfterRollbackCallTimes, committed)
+	}
+
+	p7 := Product{Code: "after_save_error", Price: 100}
+	tx = TestDB.Begin()
+	tx.Save(&p7)
+	if p7.AfterCommitCallTimes != 0 || p7.AfterRollbackCallTimes != 0 {
+		t.Errorf("A failed statement which wrote its row should wait for the transaction, %d %d", p7.AfterCommitCallTimes, p7.AfterRollbackCallTimes)
+	}
+	tx.Commit()
+	if p7.AfterCommitCallTimes != 1 || p7.AfterRollbackCallTimes != 0 {
+		t.Errorf("AfterCommit should be called when the row of a failed statement is committed, %d %d", p7.AfterCommitCallTimes, p7.AfterRollbackCallTimes)
+	}
+	if err := TestDB.First(&Product{}, p7.Id).Error; err != nil {
+		t.Errorf("The row of the failed statement should be committed, got %v", err)
+	}
+	TestDB.Delete(&p7)
+
+	p8 := Product{Code: "after_save_error", Price: 100}
+	tx = TestDB.Begin()
+	tx.Save(&p8)
+	tx.Rollback()
+	if p8.AfterCommitCallTimes != 0 || p8.AfterRollbackCallTimes != 1 {
+		t.Errorf("AfterRollback should be called when the row of a failed statement is rolled back, %d %d", p8.AfterCommitCallTimes, p8.AfterRollbackCallTimes)
+	}
+
+	p9 := Product{Code: "dont_save", Price: 100}
+	tx = TestDB.Begin()
+	tx.Save(&p9)
+	if p9.AfterRollbackCallTimes != 1 {
+		t.Errorf("AfterRollback should be called right away for a failed statement which wrote nothing, %d", p9.AfterRollbackCallTimes)
+	}
+	tx.Commit()
+	if p9.AfterCommitCallTimes != 0 || p9.AfterRollbackCallTimes != 1 {
+		t.Errorf("The outcome of the transaction should not call the methods of a statement which wrote nothing, %d %d", p9.AfterCommitCallTimes, p9.AfterRollbackCallTimes)
+	}
+
+	p5 := Product{Code: "commit_callback_savepoint", Price: 100}
+	p6 := Product{Code: "rollback_callback_savepoint", Price: 100}
+	tx = TestDB.Begin()
+	nested := tx.Begin()
+	nested.Save(&p5)
+	nested.Commit()
+	if p5.AfterCommitCallTimes != 0 {
+		t.Errorf("AfterCommit shouldn't be called when releasing a savepoint")
+	}
+	nested = tx.Begin()
+	nested.Save(&p6)
+	nested.Rollback()
+	if p6.AfterRollbackCallTimes != 1 {
+		t.Errorf("AfterRollback should be called when rolling back to a savepoint")
+	}
+	tx.Commit()
+	if p5.AfterCommitCallTimes != 1 || p6.AfterCommitCallTimes != 0 {
+		t.Errorf("AfterCommit should be called once the outer transaction commits, %d %d", p5.AfterCommitCallTimes, p6.AfterCommitCallTimes)
+	}
+
+	called := false
+	TestDB.OnCommit(func() { called = true })
+	if !called {
+		t.Errorf("OnCommit should call the function right away outside a transaction")
+	}
+}
+
+func SkipTransaction(t *testing.T) {
+	TestDB.DropTable(&TxProbe{}, &TxProbeItem{})
+	TestDB.AutoMigrate(&TxProbe{}, &TxProbeItem{})
+
+	probe := TxProbe{Name: "default"}
+	TestDB.Save(&probe)
+	if !probe.InTransaction {
+		t.Errorf("Create should run inside a transaction by default")
+	}
+
+	probe = TxProbe{Name: "skip", Items: []TxProbeItem{{Name: "item"}}}
+	TestDB.Set("gorm:skip_transaction", true).Save(&probe)
+	if probe.InTransaction {
+		t.Errorf("Create shouldn't run inside a transaction when skipping it")
+	}
+	if TestDB.Model(&probe).Association("Items").Count() != 1 {
+		t.Errorf("Associations should be saved when skipping the transaction")
+	}
+
+	probe = TxProbe{Name: "auto"}
+	TestDB.Set("gorm:skip_transaction", "auto").Save(&probe)
+	if probe.InTransaction {
+		t.Errorf("Create without associations shouldn't run inside a transaction in auto mode")
+	}
+
+	probe = TxProbe{Name: "auto-associations", Items: []TxProbeItem{{Name: "item"}}}
+	TestDB.Set("gorm:skip_transaction", "auto").Save(&probe)
+	if !probe.InTransaction {
+		t.Errorf("Create with associations should run inside a transaction in auto mode")
+	}
+	if TestDB.Model(&probe).Association("Items").Count() != 1 {
+		t.Errorf("Associations should be saved in auto mode")
+	}
+
+	p := Product{Code: "after_save_error", Price: 100}
+	if TestDB.Set("gorm:skip_transaction", true).Save(&p).Error == nil {
+		t.Errorf("The error from after save callback should be returned")
+	}
+	if err := TestDB.First(&Product{}, "code = ?", "after_save_error").Error; err != nil {
+		t.Errorf("Record can't be reverted without a transaction")
+	}
+	TestDB.Delete(&p)
+}
+
+func BuiltInCallbacks(t *testing.T) {
+	TestDB.DropTableIfExists(&StampedNote{})
+	TestDB.AutoMigrate(&StampedNote{})
+
+	var steps []string
+	reload := func(id int64) (found StampedNote) {
+		TestDB.First(&found, id)
+		return found
+	}
+	TestDB.Callback().Create().Before("gorm:create").Register("test:before_insert", func(scope *Scope) {
+		steps = append(steps, "before_insert")
+		scope.SetColumn("Body", "changed by plugin")
+	})
+	TestDB.Callback().Create().After("gorm:create").Register("test:after_insert", func(scope *Scope) {
+		steps = append(steps, "after_insert")
+	})
+	note := StampedNote{Body: "plugin"}
+	TestDB.Save(&note)
+	TestDB.Callback().Create().Remove("test:before_insert")
+	TestDB.Callback().Create().Remove("test:after_insert")
+
+	if found := reload(note.Id); found.Body != "changed by plugin" {
+		t.Errorf("A callback registered before gorm:create should change what is inserted, got %q", found.Body)
+	}
+	if !reflect.DeepEqual(steps, []string{"before_insert", "after_insert"}) {
+		t.Errorf("The callbacks should run around the insert, got %v", steps)
+	}
+
+	stamp := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	timestamps := TestDB.Callback().Create().Get("gorm:update_time_stamp_when_create")
+	TestDB.Callback().Create().Replace("gorm:update_time_stamp_when_create", func(scope *Scope) {
+		scope.SetColumn("CreatedAt", stamp)
+		scope.SetColumn("UpdatedAt", stamp)
+	})
+	note = StampedNote{Body: "replaced"}
+	TestDB.Save(&note)
+	if found := reload(note.Id); !found.CreatedAt.Equal(stamp) || !found.UpdatedAt.Equal(stamp) {
+		t.Errorf("The replaced timestamp callback should be used, got %v and %v", found.CreatedAt, found.UpdatedAt)
+	}
+
+	TestDB.Callback().Create().Remove("gorm:update_time_stamp_when_create")
+	note = StampedNote{Body: "removed"}
+	TestDB.Save(&note)
+	if !note.CreatedAt.IsZero() || !note.UpdatedAt.IsZero() {
+		t.Errorf("The removed timestamp callback should not run, got %v and %v", note.CreatedAt, note.UpdatedAt)
+	}
+
+	TestDB.Callback().Create().Replace("gorm:update_time_stamp_when_create", timestamps)
+	note = StampedNote{Body: "restored"}
+	TestDB.Save(&note)
+	if found := reload(note.Id); found.CreatedAt.IsZero() || found.CreatedAt.Equal(stamp) {
+		t.Errorf("The timestamp callback should be back in place, got %v", found.CreatedAt)
+	}
+
+	timestamps = TestDB.Callback().Update().Get("gorm:update_time_stamp_when_update")
+	TestDB.Callback().Update().Remove("gorm:update_time_stamp_when_update")
+	TestDB.Model(&note).Update("body", "updated")
+	TestDB.Callback().Update().Replace("gorm:update_time_stamp_when_update", timestamps)
+	if found := reload(note.Id); found.Body != "updated" || !found.UpdatedAt.Equal(found.CreatedAt) {
+		t.Errorf("The removed update timestamp callback should not run, got %q and %v", found.Body, found.UpdatedAt)
+	}
+
+	TestDB.Save(&Product{Code: "iterated_callbacks"})
+	var replacedFinds int
+	afterQuery := TestDB.Callback().Query().Get("gorm:after_query")
+	TestDB.Callback().Query().Replace("gorm:after_query", func(scope *Scope) {
+		replacedFinds++
+	})
+	var iterated []Product
+	TestDB.Where("code = ?", "iterated_callbacks").Iterate(&Product{}, func(product *Product) error {
+		iterated = append(iterated, *product)
+		return nil
+	})
+	TestDB.Callback().Query().Replace("gorm:after_query", afterQuery)
+	if len(iterated) != 1 || iterated[0].AfterFindCallTimes != 0 || replacedFinds != 1 {
+		t.Errorf("Iterate should call the replaced after query callback for every row, got %v and %d calls", iterated, replacedFinds)
+	}
+
+	TestDB.AutoMigrate(&CascadeBook{})
+	book := CascadeBook{Title: "restored_callbacks"}
+	TestDB.Save(&book)
+	TestDB.Delete(&book)
+	steps = nil
+	TestDB.Callback().Restore().Before("gorm:restore").Register("test:before_restore", func(scope *Scope) {
+		steps = append(steps, "before_restore")
+	})
+	TestDB.Callback().Restore().After("gorm:restore").Register("test:after_restore", func(scope *Scope) {
+		steps = append(steps, "after_restore")
+	})
+	TestDB.Restore(&book)
+	TestDB.Callback().Restore().Remove("test:before_restore")
+	TestDB.Callback().Restore().Remove("test:after_restore")
+	if !reflect.DeepEqual(steps, []string{"before_restore", "after_restore"}) || TestDB.First(&CascadeBook{}, book.Id).RecordNotFound() {
+		t.Errorf("The restore callbacks should run around the restore, got %v", steps)
+	}
+}
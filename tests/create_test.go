@@ -1,8 +1,16 @@
 package tests
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	. "github.com/badu/reGorm"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -112,6 +120,189 @@ func CreateWithNoStdPrimaryKeyAndDefaultValues(t *testing.T) {
 	}
 }
 
+func CreateSlice(t *testing.T) {
+	users := []User{
+		{Name: "batch_user_1", Age: 1, Emails: []Email{{Email: "batch_user_1@example.org"}}},
+		{Name: "batch_user_2", Age: 2},
+		{Name: "batch_user_3", Age: 3},
+	}
+	if count := TestDB.Create(&users).RowsAffected; count != 3 {
+		t.Errorf("There should be three records affected when creating a slice, got %d", count)
+	}
+	for i, user := range users {
+		if user.Id == 0 || (i > 0 && user.Id != users[i-1].Id+1) {
+			t.Errorf("Primary keys should be filled back after creating a slice, got %d", user.Id)
+		}
+		var found User
+		if TestDB.First(&found, user.Id).Error != nil || found.Name != user.Name || found.Age != user.Age {
+			t.Errorf("Element %d of the slice should be saved, got %#v", i, found)
+		}
+	}
+	if TestDB.Model(&users[0]).Association("Emails").Count() != 1 {
+		t.Errorf("Associations of the elements should be saved")
+	}
+
+	products := []*Product{{Code: "batch_product_1"}, {Code: "batch_product_2"}}
+	if err := TestDB.Create(&products).Error; err != nil {
+		t.Errorf("No error should happen when creating a slice of pointers, got %v", err)
+	}
+	for _, product := range products {
+		if product.Id == 0 || product.BeforeCreateCallTimes != 1 || product.BeforeSaveCallTimes != 1 || product.AfterSaveCallTimes != 1 || product.AfterCommitCallTimes != 1 {
+			t.Errorf("Methods should be called for every element, %v", product.GetCallTimes())
+		}
+	}
+
+	invalid := []Product{{Code: "batch_product_3"}, {Code: "Invalid"}}
+	if TestDB.Create(&invalid).Error == nil {
+		t.Errorf("An error from before create callbacks should be returned")
+	}
+	if !TestDB.First(&Product{}, "code = ?", "batch_product_3").RecordNotFound() {
+		t.Errorf("No element should be saved when one of them fails")
+	}
+
+	animals := []Animal{{Name: "batch_animal"}, {From: "batch"}, {From: "batch"}}
+	if err := TestDB.Create(&animals).Error; err != nil {
+		t.Errorf("No error should happen when creating elements with different columns, got %v", err)
+	}
+	if animals[0].Name != "batch_animal" || animals[1].Name != "galeone" || animals[2].Name != "galeone" {
+		t.Errorf("Default values should be read back for every element, got %q %q", animals[1].Name, animals[2].Name)
+	}
+	if animals[0].Counter == 0 || animals[1].Counter == 0 || animals[2].Counter != animals[1].Counter+1 {
+		t.Errorf("Primary keys should be filled back for every element")
+	}
+}
+
+func CreateInBatches(t *testing.T) {
+	var users []User
+	for i := 1; i <= 5; i++ {
+		users = append(users, User{Name: "in_batches_user", Age: int64(i)})
+	}
+
+	statements := 0
+	TestDB.Callback().Create().Register("test:count_statements", func(scope *Scope) { statements++ })
+	defer TestDB.Callback().Create().Remove("test:count_statements")
+
+	metrics := NewStatementMetrics()
+	db := TestDB.Unscoped()
+	db.SetStatementObserver(metrics)
+	if count := db.CreateInBatches(&users, 2).RowsAffected; count != 5 {
+		t.Errorf("There should be five records affected, got %d", count)
+	}
+	var count int64
+	TestDB.Model(&User{}).Where("name = ?", "in_batches_user").Count(&count)
+	if count != 5 {
+		t.Errorf("All the elements should be created, got %d", count)
+	}
+	for _, user := range users {
+		if user.Id == 0 {
+			t.Errorf("Primary keys should be filled back for every batch")
+		}
+	}
+	if statements != 1 {
+		t.Errorf("Create callbacks should be called once for the whole slice, got %d", statements)
+	}
+	if series, _ := metrics.Get("users", OperationCreate); series.Count != 3 || series.Rows != 5 {
+		t.Errorf("Five elements in batches of two should be inserted by three statements, got %d (%d rows)", series.Count, series.Rows)
+	}
+
+	names := []string{"not", "structs"}
+	if err := TestDB.Table("users").CreateInBatches(&names, 1).Error; err == nil {
+		t.Errorf("A slice of values which are not structs should not be created")
+	}
+}
+
+func Upsert(t *testing.T) {
+	TestDB.DropTable(&UpsertItem{})
+	TestDB.AutoMigrate(&UpsertItem{})
+
+	item := UpsertItem{Code: "upsert_a", Name: "first", Price: 1}
+	TestDB.Create(&item)
+
+	updated := UpsertItem{Code: "upsert_a", Name: "second", Price: 2}
+	if err := TestDB.OnConflict(OnConflict{Columns: []string{"code"}, UpdateColumns: []string{"name"}}).Create(&updated).Error; err != nil {
+		t.Errorf("No error should happen when upserting, got %v", err)
+	}
+	if updated.Id != item.Id || updated.Name != "second" || updated.Price != 1 {
+		t.Errorf("Upserted value should be read back, got %#v", updated)
+	}
+
+	ignored := UpsertItem{Code: "upsert_a", Name: "third"}
+	if err := TestDB.OnConflict(OnConflict{Columns: []string{"Code"}, DoNothing: true}).Create(&ignored).Error; err != nil {
+		t.Errorf("No error should happen when upserting with do nothing, got %v", err)
+	}
+	if ignored.Id != item.Id || ignored.Name != "second" {
+		t.Errorf("Existing row should be kept and read back, got %#v", ignored)
+	}
+
+	all := UpsertItem{Code: "upsert_a", Name: "fourth", Price: 4}
+	TestDB.OnConflict(OnConflict{Columns: []string{"code"}, UpdateAll: true, Except: []string{"Name", "CreatedAt"}}).Create(&all)
+	var found UpsertItem
+	TestDB.First(&found, item.Id)
+	if found.Name != "second" || found.Price != 4 {
+		t.Errorf("All columns except the excepted ones should be updated, got %#v", found)
+	}
+
+	items := []UpsertItem{{Code: "upsert_a", Name: "batch", Price: 5}, {Code: "upsert_b", Name: "new", Price: 6}}
+	if err := TestDB.OnConflict(OnConflict{Columns: []string{"code"}, UpdateAll: true}).Create(&items).Error; err != nil {
+		t.Errorf("No error should happen when upserting a slice, got %v", err)
+	}
+	if items[0].Id != item.Id || items[1].Id == 0 || items[1].Id == item.Id {
+		t.Errorf("Primary keys should be read back after upserting a slice, got %d %d", items[0].Id, items[1].Id)
+	}
+	var count int64
+	TestDB.Model(&UpsertItem{}).Count(&count)
+	if count != 2 {
+		t.Errorf("Upserting a slice should insert only the new rows, got %d rows", count)
+	}
+	TestDB.First(&found, item.Id)
+	if found.Name != "batch" || found.Price != 5 {
+		t.Errorf("Upserting a slice should update the existing rows, got %#v", found)
+	}
+
+	if err := TestDB.OnConflict(OnConflict{DoNothing: true}).Create(&UpsertItem{Id: item.Id, Code: "upsert_c"}).Error; err != nil {
+		t.Errorf("No error should happen when the primary key conflicts, got %v", err)
+	}
+	if !TestDB.First(&UpsertItem{}, "code = ?", "upsert_c").RecordNotFound() {
+		t.Errorf("Nothing should be inserted when the primary key conflicts")
+	}
+
+	inserted := UpsertItem{Code: "upsert_d"}
+	if err := TestDB.OnConflict(OnConflict{DoNothing: true}).Create(&inserted).Error; err != nil {
+		t.Errorf("No error should happen when upserting without conflict columns, got %v", err)
+	}
+	var insertedFound UpsertItem
+	TestDB.First(&insertedFound, "code = ?", "upsert_d")
+	if inserted.Id == 0 || inserted.Id != insertedFound.Id {
+		t.Errorf("The primary key of an inserted row should be set, got %d, expecting %d", inserted.Id, insertedFound.Id)
+	}
+
+	batch := []UpsertItem{{Code: "upsert_e"}, {Code: "upsert_f"}}
+	if err := TestDB.OnConflict(OnConflict{DoNothing: true}).Create(&batch).Error; err != nil {
+		t.Errorf("No error should happen when upserting a slice without conflict columns, got %v", err)
+	}
+	var batchFound UpsertItem
+	TestDB.First(&batchFound, "code = ?", "upsert_f")
+	if batch[0].Id == 0 || batch[1].Id != batchFound.Id {
+		t.Errorf("The primary keys of the inserted rows should be set, got %d %d", batch[0].Id, batch[1].Id)
+	}
+	single := UpsertItem{Code: "upsert_g", Name: "single"}
+	if err := TestDB.OnConflict(OnConflict{UpdateAll: true}).Create(&single).Error; err != nil {
+		t.Errorf("No error should happen when upserting without conflict columns, got %v", err)
+	}
+	var singleFound UpsertItem
+	TestDB.First(&singleFound, "code = ?", "upsert_g")
+	switch TestDB.Dialect().GetName() {
+	case "postgres", "mysql":
+		if single.Id != singleFound.Id {
+			t.Errorf("The primary key of the inserted row should be set, got %d, expecting %d", single.Id, singleFound.Id)
+		}
+	default:
+		if single.Id != 0 || singleFound.Id == 0 {
+			t.Errorf("The primary key of the upserted row can't be told, it should stay blank, got %d", single.Id)
+		}
+	}
+}
+
 func AnonymousScanner(t *testing.T) {
 	user := User{Name: "anonymous_scanner", Role: Role{Name: "admin"}}
 	TestDB.Save(&user)
@@ -174,3 +365,117 @@ func OmitWithCreate(t *testing.T) {
 		t.Errorf("Should not create omited relationships")
 	}
 }
+
+func ConstraintErrors(t *testing.T) {
+	TestDB.DropTable(&ConstrainedItem{})
+	TestDB.AutoMigrate(&ConstrainedItem{})
+	isSqlite := TestDB.Dialect().GetName() == "sqlite3"
+
+	name := "constrained"
+	if err := TestDB.Create(&ConstrainedItem{Code: "constraint_a", Name: &name}).Error; err != nil {
+		t.Fatalf("No error should happen when creating, got %v", err)
+	}
+
+	var constraintErr *ConstraintError
+	err := TestDB.Create(&ConstrainedItem{Code: "constraint_a", Name: &name}).Error
+	if !errors.Is(err, ErrDuplicateKey) || !errors.As(err, &constraintErr) {
+		t.Fatalf("A duplicate key error should be returned, got %#v", err)
+	}
+	if isSqlite {
+		var driverErr sqlite3.Error
+		if constraintErr.Table != "constrained_items" || constraintErr.Column != "code" || !errors.As(err, &driverErr) {
+			t.Errorf("The names and the driver error should be reachable, got %#v", constraintErr)
+		}
+	}
+
+	err = TestDB.Create(&ConstrainedItem{Code: "constraint_b"}).Error
+	if !errors.Is(err, ErrNotNullViolation) || errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("A not null violation should be returned, got %v", err)
+	}
+	if isSqlite && errors.As(err, &constraintErr) && constraintErr.Column != "name" {
+		t.Errorf("The not null column should be reported, got %#v", constraintErr)
+	}
+
+	err = TestDB.Create(&ConstrainedItem{Code: "constraint_c", Name: &name, Score: -1}).Error
+	if !errors.Is(err, ErrCheckViolation) {
+		t.Errorf("A check violation should be returned, got %v", err)
+	}
+
+	if err := TestDB.Create(&ConstrainedItem{Code: "constraint_d", Name: &name}).Error; err != nil {
+		t.Errorf("The connection should not keep the violations, got %v", err)
+	}
+
+	sqliteCon, _ := Open("sqlite3", TestDB.AsSQLDB())
+	if err := sqliteCon.Dialect().TranslateError(errors.New("UNIQUE constraint failed: users.email")); errors.As(err, &constraintErr) {
+		t.Errorf("Only the errors of the driver should be translated, got %#v", err)
+	}
+
+	mysqlCon, _ := Open("mysql", TestDB.AsSQLDB())
+	err = mysqlCon.Dialect().TranslateError(fmt.Errorf("insert : %w", &mysql.MySQLError{
+		Number:  1452,
+		Message: "Cannot add or update a child row: a foreign key constraint fails (`shop`.`orders`, CONSTRAINT `fk_orders_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))",
+	}))
+	var mysqlErr *mysql.MySQLError
+	if !errors.Is(err, ErrForeignKeyViolation) || !errors.As(err, &constraintErr) || !errors.As(err, &mysqlErr) {
+		t.Fatalf("The mysql error should be translated, got %#v", err)
+	}
+	if constraintErr.Table != "orders" || constraintErr.Constraint != "fk_orders_user" || constraintErr.Column != "user_id" {
+		t.Errorf("The names should be read from the mysql message, got %#v", constraintErr)
+	}
+
+	pgCon, _ := Open("postgres", TestDB.AsSQLDB())
+	err = pgCon.Dialect().TranslateError(&pq.Error{Code: "23505", Table: "users", Constraint: "users_email_key"})
+	if !errors.Is(err, ErrDuplicateKey) || !errors.As(err, &constraintErr) || constraintErr.Constraint != "users_email_key" || constraintErr.Table != "users" {
+		t.Errorf("The postgres error should be translated, got %#v", err)
+	}
+	if err := pgCon.Dialect().TranslateError(&pq.Error{Code: "42P01"}); errors.As(err, &constraintErr) {
+		t.Errorf("Other errors should not be translated, got %#v", err)
+	}
+}
+
+func StatementErrors(t *testing.T) {
+	TestDB.DropTable(&ConstrainedItem{}, &ConstrainedOwner{})
+	TestDB.AutoMigrate(&ConstrainedItem{}, &ConstrainedOwner{})
+
+	name := "statement"
+	if err := TestDB.Create(&ConstrainedItem{Code: "statement_a", Name: &name}).Error; err != nil {
+		t.Fatalf("No error should happen when creating, got %v", err)
+	}
+
+	owner := ConstrainedOwner{Name: "owner", Items: []ConstrainedItem{{Code: "statement_a", Name: &name}}}
+	err := TestDB.Save(&owner).Error
+	var statementErr *StatementError
+	if !errors.As(err, &statementErr) {
+		t.Fatalf("The error of the nested save should carry its statement, got %#v", err)
+	}
+	if statementErr.Table != "constrained_items" || statementErr.Operation != OperationCreate || statementErr.Model != reflect.TypeOf(ConstrainedItem{}) {
+		t.Errorf("The table, operation and model of the failed statement should be set, got %#v", statementErr)
+	}
+	if !strings.HasPrefix(statementErr.SQL, "INSERT INTO") || len(statementErr.Vars) == 0 {
+		t.Errorf("The failed statement should be set, got %v %v", statementErr.SQL, statementErr.Vars)
+	}
+	if !errors.Is(err, ErrDuplicateKey) || !strings.Contains(err.Error(), "constrained_items") {
+		t.Errorf("The translated error should be reachable and the table named, got %v", err)
+	}
+	if !TestDB.First(&ConstrainedOwner{}, "name = ?", "owner").RecordNotFound() {
+		t.Errorf("The owner should be rolled back")
+	}
+
+	err = TestDB.SetRedaction(RedactAll).Exec("INSERT INTO constrained_items (code, name) VALUES (?, ?)", "statement_a", name).Error
+	TestDB.SetRedaction(RedactSensitive)
+	if !errors.As(err, &statementErr) || statementErr.Operation != OperationRaw || statementErr.Vars != nil || statementErr.Model != nil {
+		t.Errorf("The raw statement should be reported without vars, got %#v", err)
+	}
+
+	var count int
+	err = TestDB.Table("constrained_items").Where("code = ?", "statement_missing").Group("code").Count(&count).Error
+	if err != sql.ErrNoRows {
+		t.Errorf("sql.ErrNoRows should be returned as is, got %#v", err)
+	}
+
+	var constraintErr *ConstraintError
+	errs := GormErrors{errors.New("first"), &StatementError{Err: &ConstraintError{Kind: ErrCheckViolation, Err: errors.New("check")}}}
+	if !errors.Is(errs, ErrCheckViolation) || !errors.As(errs, &constraintErr) || errors.Is(errs, ErrDuplicateKey) {
+		t.Errorf("Is and As should look into every error, got %v", errs)
+	}
+}
@@ -1,6 +1,8 @@
 package tests
 
 import (
+	. "github.com/badu/reGorm"
+	"strings"
 	"testing"
 	"time"
 )
@@ -66,3 +68,224 @@ func SoftDelete(t *testing.T) {
 		t.Errorf("Can't find permanently deleted record")
 	}
 }
+
+func ConfigurableSoftDelete(t *testing.T) {
+	TestDB.DropTableIfExists(&LegacyOrder{}, &FlaggedTicket{}, &EpochEvent{}, &ArchivedMessage{})
+	TestDB.AutoMigrate(&LegacyOrder{}, &FlaggedTicket{}, &EpochEvent{}, &ArchivedMessage{})
+
+	order, otherOrder := LegacyOrder{Code: "removed"}, LegacyOrder{Code: "kept"}
+	TestDB.Save(&order).Save(&otherOrder)
+	if err := TestDB.Delete(&order).Error; err != nil {
+		t.Errorf("No error should happen when deleting, got %v", err)
+	}
+	var orders []LegacyOrder
+	if TestDB.Find(&orders); len(orders) != 1 || orders[0].Code != "kept" {
+		t.Errorf("The removed order should be filtered out, got %v", orders)
+	}
+	if TestDB.Unscoped().First(&order, order.Id); order.RemovedOn == nil {
+		t.Errorf("The removal time should be set, got %#v", order)
+	}
+
+	ticket := FlaggedTicket{Title: "ticket"}
+	TestDB.Save(&ticket).Delete(&ticket)
+	if !TestDB.First(&FlaggedTicket{}, ticket.Id).RecordNotFound() {
+		t.Errorf("The flagged ticket should be filtered out")
+	}
+	if TestDB.Unscoped().First(&ticket, ticket.Id); !ticket.IsDeleted {
+		t.Errorf("The ticket should be flagged, got %#v", ticket)
+	}
+
+	event := EpochEvent{Name: "event"}
+	TestDB.Save(&event)
+	if TestDB.First(&EpochEvent{}, event.Id).RecordNotFound() {
+		t.Errorf("The event should be found before it's deleted")
+	}
+	TestDB.Delete(&event)
+	if !TestDB.First(&EpochEvent{}, event.Id).RecordNotFound() {
+		t.Errorf("The deleted event should be filtered out")
+	}
+	if TestDB.Unscoped().First(&event, event.Id); event.DeletedOn < time.Now().Add(-time.Minute).Unix() {
+		t.Errorf("The epoch of the deletion should be set, got %v", event.DeletedOn)
+	}
+
+	message := ArchivedMessage{Body: "message"}
+	TestDB.Save(&message).Delete(&message)
+	var count int
+	if TestDB.Model(&ArchivedMessage{}).Count(&count); count != 0 {
+		t.Errorf("The archived message should not be counted, got %v", count)
+	}
+	if TestDB.Unscoped().First(&message, message.Id); !message.Archived {
+		t.Errorf("The column named by SoftDeleteColumn should be set, got %#v", message)
+	}
+
+	if TestDB.Unscoped().Delete(&message); !TestDB.Unscoped().First(&ArchivedMessage{}, message.Id).RecordNotFound() {
+		t.Errorf("The unscoped delete should remove the row")
+	}
+
+	if err := TestDB.Debug().Find(&[]UnknownSoftDelete{}).Error; err == nil || !strings.Contains(err.Error(), "sometimes") {
+		t.Errorf("An unknown soft delete kind should be reported, got %v", err)
+	}
+}
+
+func RestoreSoftDeleted(t *testing.T) {
+	TestDB.DropTableIfExists(&RestorableNote{}, &FlaggedTicket{})
+	TestDB.AutoMigrate(&RestorableNote{}, &FlaggedTicket{})
+
+	first, second, third, locked := RestorableNote{Body: "first"}, RestorableNote{Body: "second"}, RestorableNote{Body: "third"}, RestorableNote{Body: "locked"}
+	TestDB.Save(&first).Save(&second).Save(&third).Save(&locked)
+	TestDB.Delete(&first).Delete(&second).Delete(&locked)
+
+	var notes []RestorableNote
+	if TestDB.OnlyTrashed().Order("id").Find(&notes); len(notes) != 3 || notes[0].Body != "first" || notes[1].Body != "second" {
+		t.Errorf("Only the deleted notes should be found, got %v", notes)
+	}
+	if !TestDB.OnlyTrashed().Where("body = ?", "third").First(&RestorableNote{}).RecordNotFound() {
+		t.Errorf("The notes which are not deleted should be filtered out")
+	}
+	var count int
+	if TestDB.Unscoped().OnlyTrashed().Model(&RestorableNote{}).Count(&count); count != 3 {
+		t.Errorf("Only trashed should win over unscoped, got %v", count)
+	}
+
+	result := TestDB.Restore(&first)
+	if result.Error != nil || result.RowsAffected != 1 || first.DeletedAt != nil {
+		t.Errorf("The note should be restored, got %#v (%v)", first, result.Error)
+	}
+	if first.BeforeRestores != 1 || first.AfterRestores != 1 {
+		t.Errorf("The restore methods should be called, got %v and %v", first.BeforeRestores, first.AfterRestores)
+	}
+	if TestDB.First(&RestorableNote{}, first.Id).RecordNotFound() {
+		t.Errorf("The restored note should be found")
+	}
+
+	if result = TestDB.Restore(&RestorableNote{}, "body = ?", "second"); result.Error != nil || result.RowsAffected != 1 {
+		t.Errorf("The note should be restored by conditions, got %v rows (%v)", result.RowsAffected, result.Error)
+	}
+	if result = TestDB.Restore(&third); result.Error != nil || result.RowsAffected != 0 {
+		t.Errorf("A note which is not deleted should not be restored, got %v rows (%v)", result.RowsAffected, result.Error)
+	}
+
+	if err := TestDB.Restore(&locked).Error; err == nil || locked.BeforeRestores != 0 {
+		t.Errorf("The error of BeforeRestore should stop the restore, got %v", err)
+	}
+	if !TestDB.First(&RestorableNote{}, locked.Id).RecordNotFound() {
+		t.Errorf("The locked note should still be deleted")
+	}
+
+	ticket := FlaggedTicket{Title: "ticket"}
+	TestDB.Save(&ticket).Delete(&ticket)
+	if TestDB.OnlyTrashed().First(&FlaggedTicket{}, ticket.Id).RecordNotFound() {
+		t.Errorf("The flagged ticket should be found among the deleted ones")
+	}
+	if TestDB.Restore(&ticket); ticket.IsDeleted || TestDB.First(&FlaggedTicket{}, ticket.Id).RecordNotFound() {
+		t.Errorf("The flagged ticket should be restored, got %#v", ticket)
+	}
+
+	if err := TestDB.ForceDelete(&third).Error; err != nil {
+		t.Errorf("No error should happen when force deleting, got %v", err)
+	}
+	if !TestDB.Unscoped().First(&RestorableNote{}, third.Id).RecordNotFound() {
+		t.Errorf("The force deleted note should be gone")
+	}
+
+	if err := TestDB.Restore(&ConcurrentItem{}).Error; err == nil {
+		t.Errorf("Restoring a model without soft delete column should fail")
+	}
+}
+
+func CascadeDelete(t *testing.T) {
+	TestDB.DropTableIfExists(&CascadeAuthor{}, &CascadeBook{}, &CascadePage{}, &CascadeProfile{}, &CascadeTag{}, "cascade_author_tags", "cascade_book_tags")
+	TestDB.AutoMigrate(&CascadeAuthor{}, &CascadeBook{}, &CascadePage{}, &CascadeProfile{}, &CascadeTag{})
+
+	newAuthor := func(name string, pages ...int) CascadeAuthor {
+		author := CascadeAuthor{
+			Name:    name,
+			Books:   []CascadeBook{{Title: name + " book", Tags: []CascadeTag{{Name: name + " book tag"}}}},
+			Profile: CascadeProfile{Bio: name + " bio"},
+			Tags:    []CascadeTag{{Name: name + " tag"}},
+		}
+		for _, number := range pages {
+			author.Books[0].Pages = append(author.Books[0].Pages, CascadePage{Number: number})
+		}
+		if err := TestDB.Save(&author).Error; err != nil {
+			t.Fatalf("No error should happen when saving the author, got %v", err)
+		}
+		return author
+	}
+	countOf := func(con *DBCon, value interface{}, where ...interface{}) int {
+		var count int
+		con.Model(value).Where(where[0], where[1:]...).Count(&count)
+		return count
+	}
+
+	author := newAuthor("tagged", 1, 2)
+	if err := TestDB.Delete(&author).Error; err != nil {
+		t.Errorf("No error should happen when deleting the author, got %v", err)
+	}
+	if countOf(TestDB, &CascadeBook{}, "cascade_author_id = ?", author.Id) != 0 {
+		t.Errorf("The books of the author should be deleted")
+	}
+	if countOf(TestDB.Unscoped(), &CascadeBook{}, "cascade_author_id = ?", author.Id) != 1 {
+		t.Errorf("The books of the author should only be soft deleted")
+	}
+	if countOf(TestDB, &CascadePage{}, "cascade_book_id = ?", author.Books[0].Id) != 0 {
+		t.Errorf("The pages of the books should be deleted too")
+	}
+	if countOf(TestDB, &CascadeProfile{}, "cascade_author_id = ?", author.Id) != 1 {
+		t.Errorf("The profile is not tagged, it should be kept")
+	}
+	if countOf(TestDB.Table("cascade_author_tags"), nil, "cascade_author_id = ?", author.Id) != 0 {
+		t.Errorf("The join rows of the author should be deleted")
+	}
+	if countOf(TestDB, &CascadeTag{}, "id = ?", author.Tags[0].Id) != 1 {
+		t.Errorf("The tags are shared, they should be kept")
+	}
+	if countOf(TestDB.Table("cascade_book_tags"), nil, "cascade_book_id = ?", author.Books[0].Id) != 1 {
+		t.Errorf("The join rows of a soft deleted book should be kept")
+	}
+
+	author = newAuthor("selected")
+	if err := TestDB.Select("Profile").Delete(&author).Error; err != nil {
+		t.Errorf("No error should happen when deleting the author, got %v", err)
+	}
+	if countOf(TestDB, &CascadeProfile{}, "cascade_author_id = ?", author.Id) != 0 {
+		t.Errorf("The selected profile should be deleted")
+	}
+
+	author = newAuthor("forced", 1)
+	if err := TestDB.ForceDelete(&author).Error; err != nil {
+		t.Errorf("No error should happen when force deleting the author, got %v", err)
+	}
+	if countOf(TestDB.Unscoped(), &CascadeBook{}, "cascade_author_id = ?", author.Id) != 0 {
+		t.Errorf("Force deleting should remove the books for good")
+	}
+	if countOf(TestDB.Table("cascade_book_tags"), nil, "cascade_book_id = ?", author.Books[0].Id) != 0 {
+		t.Errorf("Force deleting should remove the join rows of the books")
+	}
+
+	author = newAuthor("unkeyed")
+	if err := TestDB.Delete(&CascadeAuthor{}, "name = ?", "unkeyed").Error; err != nil {
+		t.Errorf("No error should happen when deleting by conditions, got %v", err)
+	}
+	if countOf(TestDB, &CascadeBook{}, "cascade_author_id = ?", author.Id) != 1 {
+		t.Errorf("Deleting without primary key should not cascade")
+	}
+
+	author = newAuthor("unlucky", 12, 13)
+	if err := TestDB.Delete(&author).Error; err == nil || !strings.Contains(err.Error(), "page 13") {
+		t.Errorf("The error of a related row should stop the delete, got %v", err)
+	}
+	if TestDB.First(&CascadeAuthor{}, author.Id).RecordNotFound() {
+		t.Errorf("The author should not be deleted")
+	}
+	if countOf(TestDB, &CascadePage{}, "cascade_book_id = ?", author.Books[0].Id) != 2 {
+		t.Errorf("The pages deleted before the error should be rolled back")
+	}
+	if countOf(TestDB, &CascadeBook{}, "cascade_author_id = ?", author.Id) != 1 {
+		t.Errorf("The book should not be deleted")
+	}
+
+	if err := TestDB.AutoMigrate(&UnknownCascade{}).Error; err == nil {
+		t.Errorf("An unknown cascade should give an error")
+	}
+}
@@ -166,6 +166,33 @@ func TestAllGorm(t *testing.T) {
 	t.Run("145) TestRegisterCallback", RegisterCallback)
 	t.Run("146) TestSkipSaveAssociation", SkipSaveAssociation)
 	t.Run("147) QueryOption", QueryOption)
+	t.Run("148) TestWithContext", WithContext)
+	t.Run("149) TestTransactionClosure", TransactionClosure)
+	t.Run("150) TestNestedTransaction", NestedTransaction)
+	t.Run("151) TestTransactionOptions", TransactionOptions)
+	t.Run("152) TestTransactionCallbacks", TransactionCallbacks)
+	t.Run("153) TestSkipTransaction", SkipTransaction)
+	t.Run("154) TestCreateSlice", CreateSlice)
+	t.Run("155) TestCreateInBatches", CreateInBatches)
+	t.Run("156) TestUpsert", Upsert)
+	t.Run("157) TestIterate", Iterate)
+	t.Run("158) TestFindInBatches", FindInBatches)
+	t.Run("159) TestDryRun", DryRun)
+	t.Run("160) TestLeveledLogger", LeveledLogger)
+	t.Run("161) TestSlowQueryLog", SlowQueryLog)
+	t.Run("162) TestStatementMetricsObserver", StatementMetricsObserver)
+	t.Run("163) TestTracingSpans", TracingSpans)
+	t.Run("164) TestRedactedLogs", RedactedLogs)
+	t.Run("165) TestConstraintErrors", ConstraintErrors)
+	t.Run("166) TestStatementErrors", StatementErrors)
+	t.Run("167) TestOperationResult", OperationResult)
+	t.Run("168) TestConcurrentOperations", ConcurrentOperations)
+	t.Run("169) TestOptimisticLocking", OptimisticLocking)
+	t.Run("170) TestConfigurableSoftDelete", ConfigurableSoftDelete)
+	t.Run("171) TestRestoreSoftDeleted", RestoreSoftDeleted)
+	t.Run("172) TestCascadeDelete", CascadeDelete)
+	t.Run("173) TestBuiltInCallbacks", BuiltInCallbacks)
+	t.Run("174) TestCallbacksOrder", CallbacksOrder)
 }
 
 func TempTestFailure(t *testing.T) {
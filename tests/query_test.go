@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 
@@ -661,3 +662,132 @@ func SelectWithVariables(t *testing.T) {
 
 	rows.Close()
 }
+
+func Iterate(t *testing.T) {
+	TestDB.Save(&User{Name: "iterate_user", Age: 3, Emails: []Email{{Email: "iterate3@example.com"}}})
+	TestDB.Save(&User{Name: "iterate_user", Age: 1, Emails: []Email{{Email: "iterate1@example.com"}}})
+	TestDB.Save(&User{Name: "iterate_user", Age: 2})
+
+	var (
+		ages  []int64
+		users []*User
+	)
+	err := TestDB.Where("name = ?", "iterate_user").Order("age").Preload("Emails").Iterate(&User{}, func(user *User) error {
+		ages = append(ages, user.Age)
+		users = append(users, user)
+		return nil
+	}).Error
+	if err != nil {
+		t.Errorf("No error should happen when iterating, got %v", err)
+	}
+	if !reflect.DeepEqual(ages, []int64{1, 2, 3}) {
+		t.Errorf("Rows should be iterated honouring the order, got %v", ages)
+	}
+	if len(users) == 3 && (len(users[0].Emails) != 1 || len(users[1].Emails) != 0 || len(users[2].Emails) != 1) {
+		t.Errorf("Preloads should run for every row")
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	result := TestDB.Where("name = ?", "iterate_user").Iterate(&User{}, func(user *User) error {
+		count++
+		return stop
+	})
+	if result.Error != stop || count != 1 {
+		t.Errorf("An error returned by the function should stop the iteration, got %v after %d rows", result.Error, count)
+	}
+
+	if TestDB.Iterate(&User{}, func(product *Product) error { return nil }).Error == nil {
+		t.Errorf("Should get an error when the function doesn't match the value")
+	}
+
+	TestDB.Save(&Product{Code: "iterate_product"})
+	TestDB.Where("code = ?", "iterate_product").Iterate(&Product{}, func(product *Product) error {
+		if product.AfterFindCallTimes != 1 {
+			t.Errorf("AfterFind should be called for every row")
+		}
+		return nil
+	})
+	TestDB.Set("gorm:skip_after_find", true).Where("code = ?", "iterate_product").Iterate(&Product{}, func(product *Product) error {
+		if product.AfterFindCallTimes != 0 {
+			t.Errorf("AfterFind shouldn't be called when skipped")
+		}
+		return nil
+	})
+}
+
+func FindInBatches(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		TestDB.Save(&User{Name: "batches_user", Age: int64(i)})
+	}
+
+	var (
+		users   []User
+		ids     []int64
+		batches int
+	)
+	result := TestDB.Where("name = ?", "batches_user").Order("age desc").FindInBatches(&users, 2, func(tx *DBCon, batch int) error {
+		batches = batch
+		if tx.RowsAffected != int64(len(users)) {
+			t.Errorf("The connection should report the rows of the batch")
+		}
+		for _, user := range users {
+			ids = append(ids, user.Id)
+		}
+		return nil
+	})
+	if result.Error != nil || result.RowsAffected != 5 || batches != 3 {
+		t.Errorf("All the rows should be found in 3 batches, got %v rows in %d batches (%v)", result.RowsAffected, batches, result.Error)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Errorf("Batches should walk the primary key order, got %v", ids)
+		}
+	}
+
+	stop := errors.New("stop")
+	batches = 0
+	result = TestDB.Where("name = ?", "batches_user").FindInBatches(&users, 2, func(tx *DBCon, batch int) error {
+		batches = batch
+		if batch == 2 {
+			return stop
+		}
+		return nil
+	})
+	if result.Error != stop || batches != 2 {
+		t.Errorf("An error returned by the function should stop the batches, got %v after %d batches", result.Error, batches)
+	}
+
+	TestDB.DropTableIfExists(&KeysetItem{})
+	TestDB.CreateTable(&KeysetItem{})
+	for _, key := range []string{"b-2", "a-2", "b-1", "a-1", "c-1"} {
+		TestDB.Create(&KeysetItem{Region: key[:1], Code: key[2:], Name: "batches"})
+	}
+	var (
+		items []KeysetItem
+		keys  []string
+	)
+	TestDB.Where("name = ?", "batches").FindInBatches(&items, 2, func(tx *DBCon, batch int) error {
+		for _, item := range items {
+			keys = append(keys, item.Region+"-"+item.Code)
+		}
+		return nil
+	})
+	if !reflect.DeepEqual(keys, []string{"a-1", "a-2", "b-1", "b-2", "c-1"}) {
+		t.Errorf("Batches should walk composite primary keys, got %v", keys)
+	}
+
+	batches = 0
+	result = TestDB.Where("name = ?", "batches_user").FindInBatches(&users, 2, func(tx *DBCon, batch int) error {
+		batches = batch
+		users = nil
+		return nil
+	})
+	if result.Error != nil || result.RowsAffected != 5 || batches != 3 {
+		t.Errorf("Changing the destination should not stop the batches, got %v rows in %d batches (%v)", result.RowsAffected, batches, result.Error)
+	}
+	var user User
+	if result = TestDB.FindInBatches(&user, 2, func(tx *DBCon, batch int) error { return nil }); result.Error == nil {
+		t.Errorf("A destination which is not a slice should be an error")
+	}
+}
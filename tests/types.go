@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
@@ -13,6 +14,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -273,20 +275,22 @@ type (
 	}
 
 	Product struct {
-		Id                    int64
-		Code                  string
-		Price                 int64
-		CreatedAt             time.Time
-		UpdatedAt             time.Time
-		AfterFindCallTimes    int64
-		BeforeCreateCallTimes int64
-		AfterCreateCallTimes  int64
-		BeforeUpdateCallTimes int64
-		AfterUpdateCallTimes  int64
-		BeforeSaveCallTimes   int64
-		AfterSaveCallTimes    int64
-		BeforeDeleteCallTimes int64
-		AfterDeleteCallTimes  int64
+		Id                     int64
+		Code                   string
+		Price                  int64
+		CreatedAt              time.Time
+		UpdatedAt              time.Time
+		AfterFindCallTimes     int64
+		BeforeCreateCallTimes  int64
+		AfterCreateCallTimes   int64
+		BeforeUpdateCallTimes  int64
+		AfterUpdateCallTimes   int64
+		BeforeSaveCallTimes    int64
+		AfterSaveCallTimes     int64
+		BeforeDeleteCallTimes  int64
+		AfterDeleteCallTimes   int64
+		AfterCommitCallTimes   int64 `sql:"-"`
+		AfterRollbackCallTimes int64 `sql:"-"`
 	}
 
 	Company struct {
@@ -295,6 +299,180 @@ type (
 		Owner *User `sql:"-"`
 	}
 
+	TxProbe struct {
+		Id            int64
+		Name          string
+		Items         []TxProbeItem
+		InTransaction bool `sql:"-"`
+	}
+
+	TxProbeItem struct {
+		Id        int64
+		TxProbeId int64
+		Name      string
+	}
+
+	KeysetItem struct {
+		Region string `gorm:"primary_key"`
+		Code   string `gorm:"primary_key"`
+		Name   string
+	}
+
+	UpsertItem struct {
+		Id        int64
+		Code      string `sql:"unique_index"`
+		Name      string
+		Price     int64
+		CreatedAt time.Time
+	}
+
+	ConstrainedItem struct {
+		Id                 int64
+		Code               string  `sql:"unique_index"`
+		Name               *string `sql:"not null"`
+		Score              int64   `sql:"type:integer CHECK (score >= 0)"`
+		ConstrainedOwnerId int64
+	}
+
+	ConstrainedOwner struct {
+		Id    int64
+		Name  string
+		Items []ConstrainedItem
+	}
+
+	ConcurrentItem struct {
+		Id     int64
+		Name   string
+		Worker int
+	}
+
+	VersionedProduct struct {
+		Id      int64
+		Name    string
+		Version Version
+	}
+
+	RevisedNote struct {
+		Id       int64
+		Body     string
+		Revision int `gorm:"version"`
+	}
+
+	LegacyOrder struct {
+		Id        int64
+		Code      string
+		RemovedOn *time.Time `gorm:"soft_delete"`
+	}
+
+	FlaggedTicket struct {
+		Id        int64
+		Title     string
+		IsDeleted bool `gorm:"soft_delete"`
+	}
+
+	EpochEvent struct {
+		Id        int64
+		Name      string
+		DeletedOn int64 `gorm:"soft_delete:unix"`
+	}
+
+	ArchivedMessage struct {
+		Id       int64
+		Body     string
+		Archived bool
+	}
+
+	RestorableNote struct {
+		Id             int64
+		Body           string
+		DeletedAt      *time.Time
+		BeforeRestores int `sql:"-"`
+		AfterRestores  int `sql:"-"`
+	}
+
+	UnknownSoftDelete struct {
+		Id        int64
+		DeletedOn int64 `gorm:"soft_delete:sometimes"`
+	}
+
+	StampedNote struct {
+		Id        int64
+		Body      string
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+
+	CascadeAuthor struct {
+		Id      int64
+		Name    string
+		Books   []CascadeBook `gorm:"cascade:delete"`
+		Profile CascadeProfile
+		Tags    []CascadeTag `gorm:"many2many:cascade_author_tags;cascade:delete"`
+	}
+
+	CascadeBook struct {
+		Id              int64
+		CascadeAuthorId int64
+		Title           string
+		Pages           []CascadePage `gorm:"cascade:delete"`
+		Tags            []CascadeTag  `gorm:"many2many:cascade_book_tags;cascade:delete"`
+		DeletedAt       *time.Time
+	}
+
+	CascadePage struct {
+		Id            int64
+		CascadeBookId int64
+		Number        int
+	}
+
+	CascadeProfile struct {
+		Id              int64
+		CascadeAuthorId int64
+		Bio             string
+	}
+
+	CascadeTag struct {
+		Id   int64
+		Name string
+	}
+
+	UnknownCascade struct {
+		Id    int64
+		Pages []CascadePage `gorm:"cascade:always"`
+	}
+
+	Credential struct {
+		Id       int64
+		Login    string
+		Password string `gorm:"sensitive"`
+		Token    string `sql:"sensitive"`
+	}
+
+	SecretKeyed struct {
+		Key  string `gorm:"primary_key;sensitive"`
+		Name string
+	}
+
+	LogEntry struct {
+		Level string
+		Msg   string
+		Args  []interface{}
+		SQL   string
+		Vars  []interface{}
+		Rows  int64
+		Err   error
+	}
+
+	RecordingLogger struct {
+		l       sync.Mutex
+		Entries []LogEntry
+	}
+
+	//keeps the vars of the statements, as the observer gets them
+	VarsObserver struct {
+		Vars [][]interface{}
+	}
+
 	Role struct {
 		Name string `gorm:"size:256"`
 	}
@@ -651,6 +829,96 @@ func (s *Product) AfterDelete() (err error) {
 	return
 }
 
+func (s *Product) AfterCommit() {
+	s.AfterCommitCallTimes = s.AfterCommitCallTimes + 1
+}
+
+func (s *Product) AfterRollback() {
+	s.AfterRollbackCallTimes = s.AfterRollbackCallTimes + 1
+}
+
+//OnCommit defers the function only when a transaction is open
+func (p *TxProbe) AfterCreate(tx *DBCon) {
+	called := false
+	tx.OnCommit(func() { called = true })
+	p.InTransaction = !called
+}
+
+func (n *RestorableNote) BeforeRestore() error {
+	if n.Body == "locked" {
+		return errors.New("locked notes can't be restored")
+	}
+	n.BeforeRestores++
+	return nil
+}
+
+func (n *RestorableNote) AfterRestore() {
+	n.AfterRestores++
+}
+
+func (p *CascadePage) BeforeDelete() error {
+	if p.Number == 13 {
+		return errors.New("page 13 can't be deleted")
+	}
+	return nil
+}
+
+func (ArchivedMessage) SoftDeleteColumn() (string, SoftDeleteKind) {
+	return "archived", SoftDeleteFlag
+}
+
+func (o *VarsObserver) StatementStarted(info *StatementInfo) {}
+
+func (o *VarsObserver) StatementFinished(info *StatementInfo) {
+	o.Vars = append(o.Vars, info.Vars)
+}
+
+func (r *RecordingLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	r.add(LogEntry{Level: "info", Msg: msg, Args: args})
+}
+
+func (r *RecordingLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	r.add(LogEntry{Level: "warn", Msg: msg, Args: args})
+}
+
+func (r *RecordingLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	r.add(LogEntry{Level: "error", Msg: msg, Args: args})
+}
+
+func (r *RecordingLogger) Trace(ctx context.Context, begin time.Time, sqlFunc func() (string, []interface{}), rowsAffected int64, err error) {
+	sql, vars := sqlFunc()
+	r.add(LogEntry{Level: "trace", SQL: sql, Vars: vars, Rows: rowsAffected, Err: err})
+}
+
+func (r *RecordingLogger) add(entry LogEntry) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.Entries = append(r.Entries, entry)
+}
+
+//entries of the level, in the order they were logged
+func (r *RecordingLogger) Get(level string) []LogEntry {
+	r.l.Lock()
+	defer r.l.Unlock()
+	var result []LogEntry
+	for _, entry := range r.Entries {
+		if entry.Level == level {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+//value of the key in key value pairs
+func LogArg(args []interface{}, key string) (interface{}, bool) {
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == key {
+			return args[i+1], true
+		}
+	}
+	return nil, false
+}
+
 func (s *Product) GetCallTimes() []int64 {
 	return []int64{s.BeforeCreateCallTimes, s.BeforeSaveCallTimes, s.BeforeUpdateCallTimes, s.AfterCreateCallTimes, s.AfterSaveCallTimes, s.AfterUpdateCallTimes, s.BeforeDeleteCallTimes, s.AfterDeleteCallTimes, s.AfterFindCallTimes}
 }
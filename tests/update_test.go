@@ -452,3 +452,75 @@ func UpdateDecodeVirtualAttributes(t *testing.T) {
 		t.Errorf("should decode virtual attributes to struct, so it could be used in callbacks")
 	}
 }
+
+func OptimisticLocking(t *testing.T) {
+	TestDB.DropTable(&VersionedProduct{}, &RevisedNote{})
+	TestDB.AutoMigrate(&VersionedProduct{}, &RevisedNote{})
+
+	product := VersionedProduct{Name: "product"}
+	if err := TestDB.Create(&product).Error; err != nil || product.Version != 1 {
+		t.Fatalf("The version should start with 1, got %v (%v)", product.Version, err)
+	}
+
+	var first, second VersionedProduct
+	TestDB.First(&first, product.Id)
+	TestDB.First(&second, product.Id)
+
+	first.Name = "first"
+	if err := TestDB.Save(&first).Error; err != nil || first.Version != 2 {
+		t.Errorf("The version should be incremented by save, got %v (%v)", first.Version, err)
+	}
+
+	second.Name = "second"
+	if err := TestDB.Save(&second).Error; err != ErrStaleObject || second.Version != 1 {
+		t.Errorf("The save of a stale value should fail, got %v (%v)", second.Version, err)
+	}
+	var count int
+	var reloaded VersionedProduct
+	if TestDB.Model(&VersionedProduct{}).Count(&count); count != 1 {
+		t.Errorf("The stale value should not be inserted, got %v rows", count)
+	}
+	if TestDB.First(&reloaded, product.Id); reloaded.Name != "first" || reloaded.Version != 2 {
+		t.Errorf("The stale value should not be saved, got %#v", reloaded)
+	}
+
+	if err := TestDB.Model(&first).Update("name", "first_update").Error; err != nil || first.Version != 3 {
+		t.Errorf("The version should be incremented by update, got %v (%v)", first.Version, err)
+	}
+	if err := TestDB.Model(&second).Updates(map[string]interface{}{"name": "second_update"}).Error; err != ErrStaleObject {
+		t.Errorf("The update of a stale value should fail, got %v", err)
+	}
+
+	if err := TestDB.Model(&second).UpdateColumn("name", "second_column").Error; err != nil {
+		t.Errorf("Update column should not check the version, got %v", err)
+	}
+	if TestDB.First(&reloaded, product.Id); reloaded.Name != "second_column" || reloaded.Version != 3 {
+		t.Errorf("Update column should not change the version, got %#v", reloaded)
+	}
+
+	if err := TestDB.Model(&VersionedProduct{}).Where("id = ?", product.Id).Update("name", "batch").Error; err != nil {
+		t.Errorf("No error should happen when updating without primary key, got %v", err)
+	}
+	if TestDB.First(&reloaded, product.Id); reloaded.Name != "batch" || reloaded.Version != 4 {
+		t.Errorf("The version should be incremented by the updates without primary key, got %#v", reloaded)
+	}
+
+	TestDB.Delete(&reloaded)
+	if err := TestDB.Save(&reloaded).Error; err != ErrStaleObject {
+		t.Errorf("The save of a deleted value should fail, got %v", err)
+	}
+	if TestDB.Model(&VersionedProduct{}).Count(&count); count != 0 {
+		t.Errorf("The deleted value should not be inserted again, got %v rows", count)
+	}
+
+	note := RevisedNote{Body: "note"}
+	TestDB.Save(&note)
+	stale := note
+	note.Body = "revised"
+	if err := TestDB.Save(&note).Error; err != nil || note.Revision != 2 {
+		t.Errorf("The field tagged version should be incremented, got %v (%v)", note.Revision, err)
+	}
+	if err := TestDB.Save(&stale).Error; err != ErrStaleObject {
+		t.Errorf("The field tagged version should be checked, got %v", err)
+	}
+}
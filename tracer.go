@@ -0,0 +1,99 @@
+package gorm
+
+import (
+	"context"
+	"sync"
+)
+
+// StartSpan returns ctx unchanged, with a span which records nothing
+func (NoopTracer) StartSpan(ctx context.Context, name string, attributes ...SpanAttribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopSpan) SetAttributes(attributes ...SpanAttribute) {}
+
+func (noopSpan) End(err error) {}
+
+// NewSpanRecorder returns an in memory Tracer
+//     recorder := gorm.NewSpanRecorder()
+//     db.SetTracer(recorder)
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{l: new(sync.Mutex)}
+}
+
+// StartSpan records a new span, child of the span carried by ctx, if any
+func (r *SpanRecorder) StartSpan(ctx context.Context, name string, attributes ...SpanAttribute) (context.Context, Span) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	span := &RecordedSpan{
+		Name:       name,
+		Attributes: make(map[string]interface{}),
+		recorder:   r,
+	}
+	for _, attribute := range attributes {
+		span.Attributes[attribute.Key] = attribute.Value
+	}
+	if parent, ok := ctx.Value(recordedSpanKey{}).(*RecordedSpan); ok && parent.recorder == r {
+		span.Parent = parent
+		parent.Children = append(parent.Children, span)
+	}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+// Spans returns every span, in the order they were started
+func (r *SpanRecorder) Spans() []*RecordedSpan {
+	r.l.Lock()
+	defer r.l.Unlock()
+	return append([]*RecordedSpan(nil), r.spans...)
+}
+
+// Roots returns the spans without parent, in the order they were started
+func (r *SpanRecorder) Roots() []*RecordedSpan {
+	r.l.Lock()
+	defer r.l.Unlock()
+	var result []*RecordedSpan
+	for _, span := range r.spans {
+		if span.Parent == nil {
+			result = append(result, span)
+		}
+	}
+	return result
+}
+
+// Reset forgets the recorded spans
+func (r *SpanRecorder) Reset() {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.spans = nil
+}
+
+// SetAttributes adds or replaces attributes of the span
+func (s *RecordedSpan) SetAttributes(attributes ...SpanAttribute) {
+	s.recorder.l.Lock()
+	defer s.recorder.l.Unlock()
+	for _, attribute := range attributes {
+		s.Attributes[attribute.Key] = attribute.Value
+	}
+}
+
+// End marks the span as ended, with the error of what it traced
+func (s *RecordedSpan) End(err error) {
+	s.recorder.l.Lock()
+	defer s.recorder.l.Unlock()
+	s.Err = err
+	s.Ended = true
+}
+
+// Find returns the first descendant with the name, depth first
+func (s *RecordedSpan) Find(name string) *RecordedSpan {
+	for _, child := range s.Children {
+		if child.Name == name {
+			return child
+		}
+		if found := child.Find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
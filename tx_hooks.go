@@ -0,0 +1,52 @@
+package gorm
+
+//shorter than append, better reading
+func (h *txHooks) onCommit(fn func()) {
+	h.commits = append(h.commits, fn)
+}
+
+func (h *txHooks) onRollback(fn func()) {
+	h.rollbacks = append(h.rollbacks, fn)
+}
+
+//called once the transaction was committed. A released savepoint is not final yet,
+//so it hands everything to the outer transaction
+func (h *txHooks) committed() {
+	commits, rollbacks := h.commits, h.rollbacks
+	h.commits, h.rollbacks = nil, nil
+	if h.parent != nil {
+		h.parent.commits = append(h.parent.commits, commits...)
+		h.parent.rollbacks = append(h.parent.rollbacks, rollbacks...)
+		return
+	}
+	for _, fn := range commits {
+		fn()
+	}
+}
+
+//called once the transaction (or the savepoint) was rolled back
+func (h *txHooks) rolledBack() {
+	rollbacks := h.rollbacks
+	h.commits, h.rollbacks = nil, nil
+	for _, fn := range rollbacks {
+		fn()
+	}
+}
+
+//the transaction (or the savepoint) was already committed or rolled back
+func (h *txHooks) isFinished() bool {
+	return h != nil && h.finished
+}
+
+//fires the hooks according to the outcome (nil safe, for connections without a transaction)
+func (h *txHooks) done(committed bool) {
+	if h == nil || h.finished {
+		return
+	}
+	h.finished = true
+	if committed {
+		h.committed()
+	} else {
+		h.rolledBack()
+	}
+}
@@ -1,9 +1,11 @@
 package gorm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"log"
+	"log/slog"
 	"os"
 	"reflect"
 	"regexp"
@@ -14,11 +16,38 @@ import (
 
 const (
 	// Callback Kind constants
-	createCallback uint8 = 1
-	updateCallback uint8 = 2
-	deleteCallback uint8 = 3
-	queryCallback  uint8 = 4
-	rowCallback    uint8 = 5
+	createCallback  uint8 = 1
+	updateCallback  uint8 = 2
+	deleteCallback  uint8 = 3
+	queryCallback   uint8 = 4
+	rowCallback     uint8 = 5
+	restoreCallback uint8 = 6
+
+	// Names of the callbacks doing the work of the operations
+	cbBeginTransaction         = "gorm:begin_transaction"
+	cbBeforeCreate             = "gorm:before_create"
+	cbBeforeUpdate             = "gorm:before_update"
+	cbBeforeDelete             = "gorm:before_delete"
+	cbSaveBeforeAssociations   = "gorm:save_before_associations"
+	cbUpdateTimeStampForCreate = "gorm:update_time_stamp_when_create"
+	cbUpdateTimeStampForUpdate = "gorm:update_time_stamp_when_update"
+	cbAssignUpdatingAttributes = "gorm:assign_updating_attributes"
+	cbCreate                   = "gorm:create"
+	cbUpdate                   = "gorm:update"
+	cbDelete                   = "gorm:delete"
+	cbDeleteAssociations       = "gorm:delete_associations"
+	cbQuery                    = "gorm:query"
+	cbPreload                  = "gorm:preload"
+	cbForceReloadAfterCreate   = "gorm:force_reload_after_create"
+	cbSaveAfterAssociations    = "gorm:save_after_associations"
+	cbAfterCreate              = "gorm:after_create"
+	cbAfterUpdate              = "gorm:after_update"
+	cbAfterDelete              = "gorm:after_delete"
+	cbAfterQuery               = "gorm:after_query"
+	cbBeforeRestore            = "gorm:before_restore"
+	cbRestore                  = "gorm:restore"
+	cbAfterRestore             = "gorm:after_restore"
+	cbCommitOrRollback         = "gorm:commit_or_rollback_transaction"
 
 	//StructField TagSettings constants
 	setMany2manyName                uint8 = 1
@@ -45,6 +74,8 @@ const (
 	setForeignDbNames               uint8 = 22 // was ForeignDBNames in Relationship struct
 	setAssociationForeignFieldNames uint8 = 23 // was AssociationForeignFieldNames in Relationship struct
 	setAssociationForeignDbNames    uint8 = 24 // was AssociationForeignDBNames in Relationship struct
+	setSoftDelete                   uint8 = 25
+	setCascade                      uint8 = 26
 
 	// Tags that can be defined `sql` or `gorm`
 	tagAutoIncrement         = "AUTO_INCREMENT"
@@ -67,6 +98,10 @@ const (
 	tagType                  = "TYPE"
 	tagUnique                = "UNIQUE"
 	tagSaveAssociations      = "SAVE_ASSOCIATIONS"
+	tagSensitive             = "SENSITIVE"
+	tagVersion               = "VERSION"
+	tagSoftDelete            = "SOFT_DELETE"
+	tagCascade               = "CASCADE"
 
 	//not really tags, but used in cachedReverseTagSettingsMap for Stringer
 	tagRelationKind           = "Relation kind"
@@ -76,7 +111,7 @@ const (
 	tagAssocForeignFieldNames = "Assoc foreign field names"
 	tagAssocForeignDbNames    = "Assoc foreign db names"
 
-	//StructField bit flags - flags are uint16, which means we can use 16 flags
+	//StructField bit flags - flags are uint32, which means we can use 32 flags
 	ffIsPrimarykey    uint8 = 0
 	ffIsNormal        uint8 = 1
 	ffIsIgnored       uint8 = 2
@@ -92,6 +127,8 @@ const (
 	ffIsAutoincrement uint8 = 12
 	ffIsPointer       uint8 = 13
 	ffRelationCheck   uint8 = 14
+	ffIsSensitive     uint8 = 15
+	ffIsVersion       uint8 = 16
 
 	//Relationship Kind constants
 	relMany2many uint8 = 1
@@ -134,17 +171,22 @@ const (
 	srchHasOmits         uint16 = 13
 	srchHasGroup         uint16 = 14
 	srchHasOffsetOrLimit uint16 = 15
+	srchIsOnlyTrashed    uint16 = 16
 
 	//Method names
-	methAfterCreate  = "AfterCreate"
-	methAfterSave    = "AfterSave"
-	methAfterDelete  = "AfterDelete"
-	methAfterFind    = "AfterFind"
-	methAfterUpdate  = "AfterUpdate"
-	methBeforeCreate = "BeforeCreate"
-	methBeforeSave   = "BeforeSave"
-	methBeforeDelete = "BeforeDelete"
-	methBeforeUpdate = "BeforeUpdate"
+	methAfterCreate   = "AfterCreate"
+	methAfterSave     = "AfterSave"
+	methAfterDelete   = "AfterDelete"
+	methAfterFind     = "AfterFind"
+	methAfterUpdate   = "AfterUpdate"
+	methAfterCommit   = "AfterCommit"
+	methAfterRollback = "AfterRollback"
+	methBeforeCreate  = "BeforeCreate"
+	methBeforeSave    = "BeforeSave"
+	methBeforeDelete  = "BeforeDelete"
+	methBeforeRestore = "BeforeRestore"
+	methAfterRestore  = "AfterRestore"
+	methBeforeUpdate  = "BeforeUpdate"
 
 	//Errors
 	errKeyNotFound         = "error TagSetting : COULDN'T FIND KEY FOR %q ON %q"
@@ -158,6 +200,21 @@ const (
 	errFieldNotFound       = "field %q not found on %q"
 	errUnsupportedRelation = "unsupported relation : %d"
 	errCantPreload         = "can't preload field %s for %s"
+	errIterateFunc         = "iterate : expecting a func(*%v) error"
+	errNoPrimaryKey        = "find in batches : %v has no primary key"
+	errBatchesDest         = "find in batches : expecting a pointer to a slice, got %T"
+	errCreateSlice         = "create : expecting a slice of structs, got %v"
+	errReturnedIds         = "create : %d rows returned for %d elements inserted into %v, the primary keys of the elements %v are unknown"
+	errOnConflictTarget    = "create : %v has no primary key, the upsert requires conflict columns"
+	errStatement           = "%s : %v"
+	errSoftDeleteKind      = "unknown soft delete kind %q, expecting time, flag or unix"
+	errNoSoftDelete        = "restore : %v has no soft delete column"
+	errCascadeKind         = "unknown cascade %q, expecting delete"
+	errCallbackName        = "callback : a name is required"
+	errCallbackDuplicated  = "callback %q of %v is already registered, use Replace"
+	errCallbackNotFound    = "callback %q of %v is not registered"
+	errCallbackCycle       = "callback %q of %v : the Before and After of the callbacks make a cycle"
+	errCallbackUnknown     = "callback %q of %v should run %v %q, which is not registered"
 	//Warnings
 	warnPolyFieldNotFound   = "\nrel : polymorphic field %q not found on model struct %q"
 	warnFkFieldNotFound     = "\nrel [%q]: foreign key field %q not found on model struct %q pointed by %q [%q]"
@@ -167,7 +224,6 @@ const (
 
 	//typical fields constants
 	fieldDefaultIdName = "id"
-	fieldDeletedAtName = "deleted_at"
 	fieldPolyType      = "Type"
 	FieldCreatedAt     = "CreatedAt"
 	FieldUpdatedAt     = "UpdatedAt"
@@ -185,6 +241,7 @@ const (
 	strCollectfks = "CollectFKs"
 	strEverything = "*"
 	strPrimaryKey = "primary key"
+	strDelete     = "DELETE"
 
 	//Gorm settings for map (Set / Get)
 	gormSettingUpdateColumn      uint64 = 1
@@ -195,13 +252,69 @@ const (
 	gormSettingSaveAssoc         uint64 = 6
 	gormSettingUpdateOpt         uint64 = 7
 	gormSettingAssociationSource uint64 = 8 //TODO : @Badu - maybe it's better to keep this info in Association struct
+	gormSettingSkipTx            uint64 = 9 // true skips the transaction opened for every write, "auto" opens it only when associations are saved (checked before the Before... methods)
+	gormSettingOnConflict        uint64 = 10
+	gormSettingSkipAfterFind     uint64 = 11
 
 	//
 	upper strCase = true
 
+	// operations reported to the StatementObserver
+	OperationCreate = "create"
+	OperationQuery  = "query"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+	OperationRaw    = "raw"
+	OperationRow    = "row"
+
+	// names of the spans started by the Tracer
+	SpanFirst       = "gorm.First"
+	SpanLast        = "gorm.Last"
+	SpanFind        = "gorm.Find"
+	SpanCreate      = "gorm.Create"
+	SpanSave        = "gorm.Save"
+	SpanUpdate      = "gorm.Update"
+	SpanDelete      = "gorm.Delete"
+	SpanExec        = "gorm.Exec"
+	SpanRestore     = "gorm.Restore"
+	SpanStatement   = "gorm.statement"
+	SpanPreload     = "gorm.preload"
+	SpanAssociation = "gorm.association"
+
 	LogOff     int = 1
 	LogVerbose int = 2
 	LogDebug   int = 3
+
+	// levels of the Logger : LogOff logs warnings and errors, LogVerbose adds the statements, LogDebug adds the stacks
+	LevelError LogLevel = 1
+	LevelWarn  LogLevel = 2
+	LevelInfo  LogLevel = 3
+	LevelDebug LogLevel = 4
+
+	// RedactSensitive the default, the values of the fields tagged `sensitive` and the values marked with Sensitive
+	// are logged as '***'
+	RedactSensitive RedactionPolicy = 0
+	// RedactNone logs every value
+	RedactNone RedactionPolicy = 1
+	// RedactAll logs the statements with placeholders only
+	RedactAll RedactionPolicy = 2
+
+	// SoftDeleteTime the column holds the time of the deletion, NULL for the rows which are not deleted
+	SoftDeleteTime SoftDeleteKind = 0
+	// SoftDeleteFlag the column is a boolean, true for the deleted rows
+	SoftDeleteFlag SoftDeleteKind = 1
+	// SoftDeleteUnix the column holds the unix time of the deletion, 0 for the rows which are not deleted
+	SoftDeleteUnix SoftDeleteKind = 2
+
+	redactedValue = "***"
+
+	colorReset  = "\033[0m"
+	colorTime   = "\033[33m"
+	colorSource = "\033[35m"
+	colorSQL    = "\033[36;1m"
+	colorInfo   = "\033[32m"
+	colorWarn   = "\033[35;1m"
+	colorError  = "\033[31;1m"
 )
 
 type (
@@ -213,7 +326,7 @@ type (
 	}
 	// StructField model field's struct definition
 	StructField struct {
-		flags       uint16
+		flags       uint32
 		DBName      string
 		StructName  string
 		Names       []string
@@ -247,6 +360,7 @@ type (
 		cachedPrimaryFields StructFields //collected from fields.fields, so we won't iterate all the time
 		ModelType           reflect.Type
 		defaultTableName    string
+		softDelete          *softDeleteColumn //nil if the rows are deleted for real
 	}
 
 	// Scope contain current operation's information when you perform any operation on the database
@@ -259,6 +373,17 @@ type (
 		rType  reflect.Type
 		//added to get rid of UPDATE_ATTRS_SETTING - since it's accessible only in that instance
 		updateMaps map[string]interface{}
+		//reported to the StatementObserver, raw if not set
+		operation string
+		//the state shared by the callbacks of an operation
+		txStarted    bool           // the transaction was started by this operation, which commits or rolls it back
+		skipLeft     bool           // the callbacks left are not called
+		updateAttrs  interface{}    // the attributes to update, nil updates every changeable field
+		queryDest    interface{}    // receives the rows instead of the value
+		iterator     *reflect.Value // called with every row instead of collecting them
+		elements     []*Scope       // the scopes of the elements of a slice being created, nil otherwise
+		batchSize    int            // how many elements are inserted by one statement, zero for all of them
+		blankColumns string         // blank columns which have default values, read back after the insert
 	}
 
 	sqlConditionType uint16
@@ -270,36 +395,78 @@ type (
 	SqlConditions map[sqlConditionType]sqlCondition
 
 	Search struct {
-		flags      uint16
+		flags      uint32
 		Conditions SqlConditions
 		tableName  string
 		SQL        string
 		SQLVars    []interface{}
 		Value      interface{} //TODO : @Badu - moved here from DBCon - in the end should use Scope's Value
+
+		//indexes of the SQLVars coming from sensitive fields
+		sensitiveVars []int
 	}
 
 	DBConFunc func(*DBCon) *DBCon
 
-	// DBCon contains information for current db connection
+	// DBCon contains information for current db connection. Every operation works on its own clone, so one
+	// connection can be shared by goroutines once configured (the Set* methods, SingularTable and the callbacks
+	// registration are not meant to run concurrently with operations). The clone is returned, see `DBCon.Result`
 	DBCon struct {
 		parent        *DBCon
 		dialect       Dialect
 		settings      map[uint64]interface{}
 		search        *Search //TODO : @Badu - should always have a Scope, not a Search - better hierarchy
 		logMode       int
-		logger        logger
+		slowThreshold time.Duration
+		observer      StatementObserver
+		tracer        Tracer
+		redaction     RedactionPolicy
+		logger        Logger
 		callbacks     *Callbacks
 		sqli          sqlInterf
+		savePoint     string          //name of the savepoint, when Begin was called inside a transaction
+		txOptions     *sql.TxOptions  //default options for the transactions started by this connection
+		ctx           context.Context //carried over to every statement, preload and association save
+		txHooks       *txHooks        //functions waiting for the outcome of the current transaction
+		dryRun        *dryRunRecorder //set by DryRun : statements are captured instead of being executed
 		singularTable bool
+		lastInsertID  int64   //primary key of the last row inserted by the operation
+		executed      *Search //last statement sent to the driver by the operation
 		Error         error
 
-		RowsAffected int64 //TODO : @Badu - this should sit inside Scope, because it's contextual
+		RowsAffected int64 //set by the operation which returned the connection, see `DBCon.Result`
 		//TODO : @Badu - add flags - which includes singularTable, future blockGlobalUpdate and logMode (encoded on 3 bytes)
 
 		modelsStructMap *safeModelStructsMap
 		namesMap        *safeMap
 		quotedNames     *safeMap
+		building        map[reflect.Type]*ModelStruct //model structs being built, cached once their relations are known
+	}
+	//queued per transaction, fired only after the transaction was committed or rolled back
+	txHooks struct {
+		parent    *txHooks //set for savepoints : on release, the functions are handed to the outer transaction
+		commits   []func()
+		rollbacks []func()
+		finished  bool //committed or rolled back, the hooks are fired only once
 	}
+
+	// Statement is a SQL statement with its variables, as captured by a DryRun session
+	Statement struct {
+		SQL  string
+		Vars []interface{}
+	}
+	//collects the statements of a DryRun session, shared by all the connections cloned from it
+	dryRunRecorder struct {
+		l          *sync.Mutex
+		statements []Statement
+	}
+	//database/sql driver which executes nothing : queries return no rows, executions report one affected row
+	dryRunConnector struct{}
+	dryRunConn      struct{}
+	dryRunStmt      struct{}
+	dryRunRows      struct{}
+	dryRunResult    struct{}
+
 	//declared to allow existing code to run, dbcon.Open(...) db = &gorm.DB{*dbcon}
 	DB struct {
 		DBCon
@@ -316,6 +483,15 @@ type (
 		DeletedAt *time.Time `sql:"index"`
 	}
 
+	// OnConflict describes what an INSERT does when it hits a unique constraint (see `DBCon.OnConflict`)
+	OnConflict struct {
+		Columns       []string // the conflict target, the primary keys if empty (mysql checks every unique index)
+		DoNothing     bool     // keep the existing row
+		UpdateColumns []string // update only these columns with the inserted values
+		UpdateAll     bool     // update all the inserted columns, except primary keys, conflict target and `Except`
+		Except        []string
+	}
+
 	//used for callbacks
 	ScopedFuncs []*ScopedFunc
 	ScopedFunc  func(*Scope)
@@ -327,14 +503,18 @@ type (
 	//   Field `updates` contains callbacks will be call when updating object
 	//   Field `deletes` contains callbacks will be call when deleting object
 	//   Field `queries` contains callbacks will be call when querying object with query methods like Find, First, Related, Association...
+	//   Field `readRows` contains the query callbacks placed after "gorm:query", which Iterate calls for every row
 	//   Field `rowQueries` contains callbacks will be call when querying object with Row, Rows...
+	//   Field `restores` contains callbacks will be call when restoring soft deleted objects
 	//   Field `processors` contains all callback processors, will be used to generate above callbacks in order
 	Callbacks struct {
 		creates    ScopedFuncs
 		updates    ScopedFuncs
 		deletes    ScopedFuncs
 		queries    ScopedFuncs
+		readRows   ScopedFuncs
 		rowQueries ScopedFuncs
+		restores   ScopedFuncs
 		processors CallbacksProcessors
 	}
 
@@ -344,10 +524,9 @@ type (
 		name      string      // current callback's name
 		before    string      // register current callback before a callback
 		after     string      // register current callback after a callback
-		replace   bool        // replace callbacks with same name
-		remove    bool        // delete callbacks with same name
 		kind      uint8       // callback type: create, update, delete, query, row_query
 		processor *ScopedFunc // callback handler
+		removed   bool        // kept in place, so Replace puts it back where it was
 		parent    *Callbacks
 	}
 
@@ -388,8 +567,9 @@ type (
 	}
 
 	safeModelStructsMap struct {
-		m map[reflect.Type]*ModelStruct
-		l *sync.RWMutex
+		m     map[reflect.Type]*ModelStruct
+		l     *sync.RWMutex
+		build *sync.Mutex //model structs are built one at a time, so no one reads them half built
 	}
 
 	safeMap struct {
@@ -397,18 +577,169 @@ type (
 		l *sync.RWMutex
 	}
 
-	logger interface {
-		Print(v ...interface{})
+	// Logger leveled logger of the connection, `Trace` receives every executed statement
+	Logger interface {
+		Info(ctx context.Context, msg string, args ...interface{})
+		Warn(ctx context.Context, msg string, args ...interface{})
+		Error(ctx context.Context, msg string, args ...interface{})
+		Trace(ctx context.Context, begin time.Time, sqlFunc func() (string, []interface{}), rowsAffected int64, err error)
+	}
+
+	// StatementObserver is notified before and after every statement is sent to the driver, with the same StatementInfo
+	StatementObserver interface {
+		StatementStarted(info *StatementInfo)
+		StatementFinished(info *StatementInfo)
+	}
+
+	// Result the outcome of an operation, see `DBCon.Result`. LastInsertID is the integer primary key
+	// of the last inserted row and SQL is the last statement sent to the driver, with its vars redacted
+	// by the connection's policy
+	Result struct {
+		Error        error
+		RowsAffected int64
+		LastInsertID int64
+		SQL          string
+		Vars         []interface{}
+	}
+
+	// StatementInfo a statement sent to the driver, its Vars following the RedactionPolicy. RowsAffected is -1
+	// for the rows handed to the caller (Row and Rows)
+	StatementInfo struct {
+		Ctx          context.Context
+		Operation    string
+		Table        string
+		SQL          string
+		Vars         []interface{}
+		Begin        time.Time
+		Duration     time.Duration
+		RowsAffected int64
+		Error        error
+		span         Span
+	}
+
+	// Tracer starts the spans of the operations and of the statements, preloads and associations saves they run.
+	// The returned context carries the span : the spans started with it are its children
+	Tracer interface {
+		StartSpan(ctx context.Context, name string, attributes ...SpanAttribute) (context.Context, Span)
+	}
+
+	// Span ends with the error of what it traced, if any
+	Span interface {
+		SetAttributes(attributes ...SpanAttribute)
+		End(err error)
+	}
+
+	SpanAttribute struct {
+		Key   string
+		Value interface{}
+	}
+
+	// NoopTracer is the default Tracer, which traces nothing
+	NoopTracer struct{}
+
+	noopSpan struct{}
+
+	// SpanRecorder in memory Tracer, keeps the spans in the order they were started
+	SpanRecorder struct {
+		l     *sync.Mutex
+		spans []*RecordedSpan
+	}
+
+	// RecordedSpan a span kept by the SpanRecorder, read it after it ended
+	RecordedSpan struct {
+		Name       string
+		Attributes map[string]interface{}
+		Parent     *RecordedSpan
+		Children   []*RecordedSpan
+		Err        error
+		Ended      bool
+		recorder   *SpanRecorder
+	}
+
+	recordedSpanKey struct{}
+
+	// StatementMetrics in memory StatementObserver, aggregates the statements per table and operation
+	StatementMetrics struct {
+		l       *sync.Mutex
+		buckets []time.Duration
+		series  map[statementKey]*StatementSeries
+	}
+
+	statementKey struct {
+		table     string
+		operation string
+	}
+
+	// StatementSeries the aggregated statements of a table and operation.
+	// Histogram[i] counts the statements not slower than Buckets[i], the last one counts the slower ones
+	StatementSeries struct {
+		Table     string
+		Operation string
+		Count     int64
+		Errors    int64
+		Rows      int64
+		Total     time.Duration
+		Max       time.Duration
+		Buckets   []time.Duration
+		Histogram []int64
+	}
+
+	// ConstraintError a constraint violation reported by the database, translated by the Dialect.
+	// `errors.Is` matches Kind (one of ErrDuplicateKey, ErrForeignKeyViolation, ErrNotNullViolation or ErrCheckViolation),
+	// `errors.As` reaches the driver error. The names are set when the database reports them
+	ConstraintError struct {
+		Kind       error
+		Table      string
+		Column     string
+		Constraint string
+		Err        error
+	}
+
+	// StatementError an error returned while executing a statement, with what was executed.
+	// Vars follow the RedactionPolicy, Model is nil for raw statements
+	StatementError struct {
+		SQL       string
+		Vars      []interface{}
+		Table     string
+		Model     reflect.Type
+		Operation string
+		Err       error
 	}
 
+	// RedactionPolicy how the logged statements show their vars
+	RedactionPolicy uint8
+
+	// Version the column used for optimistic locking (as does an integer field tagged `version`) : it's set to 1
+	// on create, incremented by every update and checked by the updates of a value with primary key, which fail
+	// with ErrStaleObject if someone else updated the row meanwhile
+	//    type Product struct {
+	//      ID      uint
+	//      Version gorm.Version
+	//    }
+	Version int64
+
+	//value of a sensitive field, on its way to the vars
+	sensitiveVar struct {
+		value interface{}
+	}
+
+	// LogLevel what the connection sends to the Logger
+	LogLevel int
+
 	// LogWriter log writer interface
 	LogWriter interface {
 		Println(v ...interface{})
 	}
 
-	// Logger default logger
-	Logger struct {
+	// StdLogger default logger, writes lines to a LogWriter (`*log.Logger` is one)
+	StdLogger struct {
 		LogWriter
+		Colorful bool
+	}
+
+	// SlogLogger adapts a `*slog.Logger`
+	SlogLogger struct {
+		*slog.Logger
 	}
 
 	errorsInterface interface {
@@ -417,6 +748,23 @@ type (
 	// Errors contains all happened errors
 	GormErrors []error
 
+	// SoftDeleteKind how a soft delete column marks the deleted rows
+	SoftDeleteKind uint8
+
+	// SoftDeleter names the column which marks the deleted rows of the model, instead of a field tagged
+	// `soft_delete` (`soft_delete:flag`, `soft_delete:unix`) or the DeletedAt field
+	//    func (Order) SoftDeleteColumn() (string, gorm.SoftDeleteKind) {
+	//      return "is_deleted", gorm.SoftDeleteFlag
+	//    }
+	SoftDeleter interface {
+		SoftDeleteColumn() (string, SoftDeleteKind)
+	}
+
+	softDeleteColumn struct {
+		column string
+		kind   SoftDeleteKind
+	}
+
 	//interface used for overriding table name
 	tabler interface {
 		TableName() string
@@ -431,10 +779,14 @@ type (
 		Prepare(query string) (*sql.Stmt, error)
 		Query(query string, args ...interface{}) (*sql.Rows, error)
 		QueryRow(query string, args ...interface{}) *sql.Row
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+		QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 	}
 	//interface
 	sqlDb interface {
 		Begin() (*sql.Tx, error)
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 	}
 	//interface
 	sqlTx interface {
@@ -492,16 +844,41 @@ type (
 		SelectFromDummyTable() string
 		// LastInsertIdReturningSuffix most dbs support LastInsertId, but postgres needs to use `RETURNING`
 		LastInsertIDReturningSuffix(tableName, columnName string) string
+		// FirstInsertID returns the id of the first row inserted by a multiple rows INSERT, from the reported LastInsertId
+		// (mysql reports the first row, sqlite the last one). The ids of the rows are taken as consecutive, one
+		// auto increment step apart : mysql with innodb_autoinc_lock_mode = 2 can interleave them with the ids
+		// of concurrent inserts
+		FirstInsertID(lastInsertID, rows int64) int64
+		// AutoIncrementStepSQL returns the query which reads the increment between consecutive ids, empty if it's always 1
+		AutoIncrementStepSQL() string
+		// OnConflictSQL returns the upsert clause for the (quoted) conflict target and columns to update,
+		// no columns to update meaning "do nothing"
+		OnConflictSQL(conflictColumns, updateColumns []string) string
+		// UpsertInserted tells if a single row upsert which updates on conflict inserted its row, from the reported
+		// RowsAffected (mysql reports 2 for an update, the others report 1 either way)
+		UpsertInserted(rowsAffected int64) bool
 		// BuildForeignKeyName returns a foreign key name for the given table, field and reference
 		BuildForeignKeyName(tableName, field, dest string) string
 		// CurrentDatabase return current database name
 		CurrentDatabase() string
+		// SavePointSQL returns the statements which create, release and roll back to the named savepoint,
+		// used for nesting transactions
+		SavePointSQL(name string) (savePoint, release, rollbackTo string)
+		// TranslateError returns a *ConstraintError for the constraint violations reported by the driver, err otherwise
+		TranslateError(err error) error
 	}
 )
 
 var (
 	dialectsMap = map[string]Dialect{}
 
+	//used for generating unique savepoint names for nested transactions
+	savePointsCounter uint64
+
+	//opened once, on the first DryRun
+	dryRunDB     *sql.DB
+	dryRunDBOnce sync.Once
+
 	// Copied from golint
 	commonInitialisms         = []string{"API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP", "HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA", "SMTP", "SSH", "TLS", "TTL", "UI", "UID", "UUID", "URI", "URL", "UTF8", "VM", "XML", "XSRF", "XSS"}
 	commonInitialismsReplacer *strings.Replacer
@@ -521,7 +898,7 @@ var (
 		return time.Now()
 	}
 
-	defaultLogger = Logger{log.New(os.Stdout, "\r\n", 0)}
+	defaultLogger = StdLogger{LogWriter: log.New(os.Stdout, "\r\n", 0), Colorful: isTerminal(os.Stdout)}
 
 	//reverse map to allow external settings
 	gormSettingsMap = map[string]uint64{
@@ -533,6 +910,9 @@ var (
 		"gorm:query_option":       gormSettingQueryOpt,
 		"gorm:save_associations":  gormSettingSaveAssoc,
 		"gorm:association:source": gormSettingAssociationSource,
+		"gorm:skip_transaction":   gormSettingSkipTx,
+		"gorm:on_conflict":        gormSettingOnConflict,
+		"gorm:skip_after_find":    gormSettingSkipAfterFind,
 	}
 
 	//this is a map for transforming strings into uint8 when reading tags of structs
@@ -559,6 +939,23 @@ var (
 		tagForeignDbNames:         setForeignDbNames,
 		tagAssocForeignFieldNames: setAssociationForeignFieldNames,
 		tagAssocForeignDbNames:    setAssociationForeignDbNames,
+		tagSoftDelete:             setSoftDelete,
+		tagCascade:                setCascade,
+	}
+
+	softDeleteKinds = map[string]SoftDeleteKind{
+		"TIME": SoftDeleteTime,
+		"FLAG": SoftDeleteFlag,
+		"UNIX": SoftDeleteUnix,
+	}
+
+	callbackKindNames = map[uint8]string{
+		createCallback:  "create",
+		updateCallback:  "update",
+		deleteCallback:  "delete",
+		queryCallback:   "query",
+		rowCallback:     "row",
+		restoreCallback: "restore",
 	}
 
 	kindNamesMap = map[uint8]string{
@@ -568,11 +965,32 @@ var (
 		relBelongsTo: "Belongs to",
 	}
 
-	regexpSelf   = regexp.MustCompile(`gorm/.*.go`)
-	regexpTest   = regexp.MustCompile(`gorm/tests/.*.go`)
 	regExpLogger = regexp.MustCompile(`(\$\d+)|\?`)
 
+	//names in the mysql errors messages
+	regExpMysqlQuoted     = regexp.MustCompile(`'([^']+)'`)
+	regExpMysqlDuplicate  = regexp.MustCompile(`for key '([^']+)'`)
+	regExpMysqlForeignKey = regexp.MustCompile("`([^`]+)`, CONSTRAINT `([^`]+)` FOREIGN KEY \\(`([^`]+)`")
+
+	defaultLatencyBuckets = []time.Duration{
+		time.Millisecond,
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		5 * time.Second,
+	}
+
+	//frames from the package sources are not the caller, except for the tests
+	sourceDir      = callerDir() + "/"
+	sourceTestsDir = sourceDir + "tests/"
+
 	cachedReverseTagSettingsMap map[uint8]string
+	//used for checking the functions received by Iterate
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	versionType = reflect.TypeOf(Version(0))
 	// only matches string like `name`, `users.name`
 	regExpNameMatcher = regexp.MustCompile("^[a-zA-Z]+(\\.[a-zA-Z]+)*$")
 	// only matches numbers
@@ -611,4 +1029,19 @@ var (
 
 	// ErrUnaddressable unaddressable value
 	ErrUnaddressable = errors.New("using unaddressable value")
+
+	// ErrDuplicateKey a unique index or primary key was violated, see ConstraintError
+	ErrDuplicateKey = errors.New("duplicate key")
+
+	// ErrForeignKeyViolation a foreign key was violated, see ConstraintError
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+
+	// ErrNotNullViolation a NULL was written into a NOT NULL column, see ConstraintError
+	ErrNotNullViolation = errors.New("not null violation")
+
+	// ErrCheckViolation a check constraint was violated, see ConstraintError
+	ErrCheckViolation = errors.New("check violation")
+
+	// ErrStaleObject the row was updated (or deleted) by someone else since the value was read, see Version
+	ErrStaleObject = errors.New("stale object")
 )
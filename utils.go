@@ -5,8 +5,10 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"path"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 )
 
@@ -250,13 +252,18 @@ func updatedAttrsWithValues(scope *Scope, value interface{}) (map[string]interfa
 				hasUpdate = true
 				results[field.DBName] = value
 			} else {
+				//the field gets the value, the vars get it marked as sensitive
+				_, sensitive := value.(sensitiveVar)
+				if sensitive {
+					value = value.(sensitiveVar).value
+				}
 				err := field.Set(value)
 				if field.IsNormal() {
 					hasUpdate = true
 					if err == ErrUnaddressable {
-						results[field.DBName] = value
+						results[field.DBName] = markSensitive(value, sensitive)
 					} else {
-						results[field.DBName] = field.Value.Interface()
+						results[field.DBName] = markSensitive(field.Value.Interface(), sensitive)
 					}
 				}
 			}
@@ -314,6 +321,17 @@ func FieldColumn(value reflect.Value, name string) reflect.Value {
 	return result
 }
 
+//the value of an integer kind, zero for any other kind
+func intValue(value reflect.Value) int64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint())
+	}
+	return 0
+}
+
 func GetType(value interface{}) reflect.Type {
 	result := reflect.ValueOf(value).Type()
 
@@ -360,14 +378,20 @@ func fullFileWithLineNum() string {
 	return result
 }
 
+//directory of this file, the way runtime reports it
+func callerDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return path.Dir(file)
+}
+
 func fileWithLineNum() string {
 	for i := 2; i < 12; i++ {
 		_, file, line, ok := runtime.Caller(i)
 		if ok {
 			//if it's our test
-			if regexpTest.MatchString(file) {
+			if strings.HasPrefix(file, sourceTestsDir) {
 				return fmt.Sprintf("%v:%v", file, line)
-			} else if !regexpSelf.MatchString(file) {
+			} else if !strings.HasPrefix(file, sourceDir) {
 				return fmt.Sprintf("%v:%v", file, line)
 			}
 		}
@@ -425,10 +449,11 @@ func Open(dialectName string, args ...interface{}) (*DBCon, error) {
 	db = DBCon{
 		dialect:         conDialect,
 		logger:          defaultLogger,
-		callbacks:       &Callbacks{},
+		tracer:          NoopTracer{},
+		callbacks:       newCallbacks(),
 		settings:        map[uint64]interface{}{},
 		sqli:            dbSQL,
-		modelsStructMap: &safeModelStructsMap{l: new(sync.RWMutex), m: make(map[reflect.Type]*ModelStruct)},
+		modelsStructMap: &safeModelStructsMap{l: new(sync.RWMutex), build: new(sync.Mutex), m: make(map[reflect.Type]*ModelStruct)},
 		namesMap:        &safeMap{l: new(sync.RWMutex), m: make(map[string]string)},
 		quotedNames:     &safeMap{l: new(sync.RWMutex), m: make(map[string]string)},
 	}